@@ -15,56 +15,125 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
-	api_adapter_v1 "github.com/sgnl-ai/adapter-framework/api/adapter/v1"
-	"github.com/sgnl-ai/adapter-framework/server"
-	"github.com/tksarunachalam/sgnl-adapter/pkg/adapter"
-	"google.golang.org/grpc"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/adapterserver"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/logging"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/metrics"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/serverconfig"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/tracing"
 )
 
-var (
-	// Port is the port at which the gRPC server will listen.
-	Port = flag.Int("port", 8080, "The server port")
+func main() {
+	cfg, err := serverconfig.Load(os.Args[0], os.Args[1:], os.LookupEnv, os.Stderr)
+	if err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+		}
 
-	// Timeout is the timeout for the HTTP client used to make requests to the datasource (seconds).
-	Timeout = flag.Int("timeout", 30, "The timeout for the HTTP client used to make requests to the datasource (seconds)")
-)
+		os.Exit(2)
+	}
 
-func main() {
-	logger := log.New(os.Stdout, "adapter", log.Lmicroseconds|log.LUTC|log.Lshortfile)
+	// The level and format are valid once the configuration is validated.
+	level, _ := cfg.Logging.SlogLevel()
 
-	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", *Port))
+	logger, err := logging.New(os.Stdout, level, cfg.Logging.Format)
 	if err != nil {
-		logger.Fatalf("Failed to open server port: %v", err)
+		fmt.Fprintf(os.Stderr, "Invalid log format: %v\n", err)
+		os.Exit(2)
 	}
 
-	// SCAFFOLDING #1 - cmd/adapter/main.go: Pass options to configure TLS, connection parameters.
-	s := grpc.NewServer()
+	logger.Info("Loaded configuration", slog.Any("config", cfg))
 
-	stop := make(chan struct{})
+	listener, err := net.Listen("tcp", cfg.ListenAddress)
+	if err != nil {
+		fatal(logger, "Failed to open server port", slog.Any("error", err))
+	}
 
-	adapterServer := server.New(stop)
+	shutdownTracing, err := tracing.Setup(context.Background())
+	if err != nil {
+		fatal(logger, "Failed to set up tracing", slog.Any("error", err))
+	}
 
-	// SCAFFOLDING #2 - cmd/adapter/main.go: Update Adapter type.
-	// The Adapter type below must be unique across all registered Adapters and match the Adapter
-	// type configured on the Adapter object via the SGNL Config API.
-	//
-	// If you need to run multiple adapters on the same gRPC server, they can be registered here.
-	err = server.RegisterAdapter(adapterServer, "Test-1.0.0", adapter.NewAdapter(adapter.NewClient(*Timeout)))
+	adapterServer, err := adapterserver.New(cfg, logger)
 	if err != nil {
-		logger.Fatalf("Failed to register adapter: %v", err)
+		fatal(logger, "Failed to create adapter server", slog.Any("error", err))
 	}
 
-	api_adapter_v1.RegisterAdapterServer(s, adapterServer)
+	serveErr := make(chan error, 1)
+
+	var metricsServer *http.Server
+
+	if cfg.Metrics.Port != 0 {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics.Handler())
 
-	logger.Printf("Started adapter gRPC server on port %d", *Port)
+		metricsServer = &http.Server{
+			Addr:              fmt.Sprintf(":%d", cfg.Metrics.Port),
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
 
-	if err := s.Serve(listener); err != nil {
-		logger.Fatalf("Failed to listen on server port: %v", err)
+		go func() {
+			if err := metricsServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				serveErr <- fmt.Errorf("metrics server: %w", err)
+			}
+		}()
+
+		logger.Info("Started metrics HTTP server", slog.Int("port", cfg.Metrics.Port))
 	}
+
+	go func() {
+		serveErr <- adapterServer.Serve(listener)
+	}()
+
+	logger.Info("Started adapter gRPC server", slog.String("address", listener.Addr().String()))
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	select {
+	case err := <-serveErr:
+		fatal(logger, "Failed to serve", slog.Any("error", err))
+	case sig := <-signals:
+		logger.Info("Shutting down", slog.String("signal", sig.String()))
+	}
+
+	adapterServer.Shutdown()
+
+	// Flush the spans of the drained requests.
+	tracingCtx, cancelTracing := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelTracing()
+
+	if err := shutdownTracing(tracingCtx); err != nil {
+		logger.Error("Failed to flush traces", slog.Any("error", err))
+	}
+
+	if metricsServer != nil {
+		// Metrics are served until every request is drained, for their last values to be scraped.
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := metricsServer.Shutdown(ctx); err != nil {
+			logger.Error("Failed to stop metrics HTTP server", slog.Any("error", err))
+		}
+	}
+
+	logger.Info("Stopped adapter gRPC server")
+}
+
+// fatal logs the error and exits.
+func fatal(logger *slog.Logger, msg string, args ...any) {
+	logger.Error(msg, args...)
+	os.Exit(1)
 }
@@ -0,0 +1,255 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+	api_adapter_v1 "github.com/sgnl-ai/adapter-framework/api/adapter/v1"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/adapter"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+// newGRPCPageGetter returns a pageGetter that sends requests to the adapter server at
+// -grpc-address, and a function that closes the connection.
+func newGRPCPageGetter(opts *options) (pageGetter, func(), error) {
+	creds := insecure.NewCredentials()
+
+	if opts.grpcTLS || opts.grpcCAFile != "" {
+		tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+		if opts.grpcCAFile != "" {
+			caPEM, err := os.ReadFile(opts.grpcCAFile)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to read CA certificate: %w", err)
+			}
+
+			tlsConfig.RootCAs = x509.NewCertPool()
+			if !tlsConfig.RootCAs.AppendCertsFromPEM(caPEM) {
+				return nil, nil, fmt.Errorf("no PEM encoded certificate in %s", opts.grpcCAFile)
+			}
+		}
+
+		creds = credentials.NewTLS(tlsConfig)
+	}
+
+	conn, err := grpc.Dial(opts.grpcAddress, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to adapter server: %w", err)
+	}
+
+	client := api_adapter_v1.NewAdapterClient(conn)
+
+	getPage := func(ctx context.Context, request *framework.Request[adapter.Config]) framework.Response {
+		req, err := grpcRequest(opts.grpcAdapterType, request)
+		if err != nil {
+			return framework.NewGetPageResponseError(&framework.Error{
+				Message: err.Error(),
+				Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_PAGE_REQUEST_CONFIG,
+			})
+		}
+
+		// The adapter server authenticates requests with the token in their metadata.
+		ctx = metadata.AppendToOutgoingContext(ctx, "token", opts.grpcToken)
+
+		res, err := client.GetPage(ctx, req)
+		if err != nil {
+			return framework.NewGetPageResponseError(&framework.Error{
+				Message: fmt.Sprintf("GetPage call failed: %v.", err),
+				Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INTERNAL,
+			})
+		}
+
+		return frameworkResponse(req.Entity, res)
+	}
+
+	return getPage, func() { conn.Close() }, nil
+}
+
+// grpcRequest converts a request into a GetPage RPC request of the adapter type. The IDs of the
+// entities and attributes are their external IDs.
+func grpcRequest(adapterType string, request *framework.Request[adapter.Config]) (*api_adapter_v1.GetPageRequest, error) {
+	var config []byte
+
+	if request.Config != nil {
+		var err error
+		if config, err = json.Marshal(request.Config); err != nil {
+			return nil, fmt.Errorf("failed to marshal config: %w", err)
+		}
+	}
+
+	datasource := &api_adapter_v1.DatasourceConfig{
+		Id:      "adapterctl",
+		Type:    adapterType,
+		Address: request.Address,
+		Config:  config,
+	}
+
+	if auth := request.Auth; auth != nil {
+		switch {
+		case auth.Basic != nil:
+			datasource.Auth = &api_adapter_v1.DatasourceAuthCredentials{
+				AuthMechanism: &api_adapter_v1.DatasourceAuthCredentials_Basic_{
+					Basic: &api_adapter_v1.DatasourceAuthCredentials_Basic{
+						Username: auth.Basic.Username,
+						Password: auth.Basic.Password,
+					},
+				},
+			}
+		case auth.HTTPAuthorization != "":
+			datasource.Auth = &api_adapter_v1.DatasourceAuthCredentials{
+				AuthMechanism: &api_adapter_v1.DatasourceAuthCredentials_HttpAuthorization{
+					HttpAuthorization: auth.HTTPAuthorization,
+				},
+			}
+		default:
+			return nil, errors.New("request auth contains no credentials")
+		}
+	}
+
+	entity := grpcEntity(&request.Entity)
+	entity.Ordered = request.Ordered
+
+	return &api_adapter_v1.GetPageRequest{
+		Datasource: datasource,
+		Entity:     entity,
+		PageSize:   request.PageSize,
+		Cursor:     request.Cursor,
+	}, nil
+}
+
+func grpcEntity(entity *framework.EntityConfig) *api_adapter_v1.EntityConfig {
+	converted := &api_adapter_v1.EntityConfig{
+		Id:         entity.ExternalId,
+		ExternalId: entity.ExternalId,
+	}
+
+	for _, attribute := range entity.Attributes {
+		converted.Attributes = append(converted.Attributes, &api_adapter_v1.AttributeConfig{
+			Id:         attribute.ExternalId,
+			ExternalId: attribute.ExternalId,
+			Type:       api_adapter_v1.AttributeType(attribute.Type),
+			List:       attribute.List,
+		})
+	}
+
+	for _, child := range entity.ChildEntities {
+		converted.ChildEntities = append(converted.ChildEntities, grpcEntity(child))
+	}
+
+	return converted
+}
+
+// frameworkResponse converts a GetPage RPC response into a framework response, for it to be
+// printed as the response of an in-process call.
+func frameworkResponse(entity *api_adapter_v1.EntityConfig, res *api_adapter_v1.GetPageResponse) framework.Response {
+	if rpcErr := res.GetError(); rpcErr != nil {
+		adapterErr := &framework.Error{Message: rpcErr.Message, Code: rpcErr.Code}
+
+		if rpcErr.RetryAfter != nil {
+			retryAfter := rpcErr.RetryAfter.AsDuration()
+			adapterErr.RetryAfter = &retryAfter
+		}
+
+		return framework.NewGetPageResponseError(adapterErr)
+	}
+
+	page := &framework.Page{NextCursor: res.GetSuccess().GetNextCursor()}
+
+	for _, object := range res.GetSuccess().GetObjects() {
+		page.Objects = append(page.Objects, frameworkObject(entity, object))
+	}
+
+	return framework.Response{Success: page}
+}
+
+func frameworkObject(entity *api_adapter_v1.EntityConfig, object *api_adapter_v1.Object) framework.Object {
+	converted := framework.Object{}
+
+	lists := make(map[string]bool, len(entity.Attributes))
+	for _, attribute := range entity.Attributes {
+		lists[attribute.Id] = attribute.List
+	}
+
+	for _, attribute := range object.Attributes {
+		values := make([]any, 0, len(attribute.Values))
+		for _, value := range attribute.Values {
+			values = append(values, frameworkValue(value))
+		}
+
+		switch {
+		case lists[attribute.Id]:
+			converted[attribute.Id] = values
+		case len(values) > 0:
+			converted[attribute.Id] = values[0]
+		}
+	}
+
+	for _, children := range object.ChildObjects {
+		childEntity := &api_adapter_v1.EntityConfig{}
+
+		for _, child := range entity.ChildEntities {
+			if child.Id == children.EntityId {
+				childEntity = child
+			}
+		}
+
+		objects := make([]framework.Object, 0, len(children.Objects))
+		for _, child := range children.Objects {
+			objects = append(objects, frameworkObject(childEntity, child))
+		}
+
+		converted[children.EntityId] = objects
+	}
+
+	return converted
+}
+
+func frameworkValue(value *api_adapter_v1.AttributeValue) any {
+	switch v := value.Value.(type) {
+	case *api_adapter_v1.AttributeValue_BoolValue:
+		return v.BoolValue
+	case *api_adapter_v1.AttributeValue_DatetimeValue:
+		zone := time.FixedZone("", int(v.DatetimeValue.TimezoneOffset))
+
+		return v.DatetimeValue.Timestamp.AsTime().In(zone)
+	case *api_adapter_v1.AttributeValue_DoubleValue:
+		return v.DoubleValue
+	case *api_adapter_v1.AttributeValue_DurationValue:
+		return framework.Duration{
+			Nanos:   v.DurationValue.Nanos,
+			Seconds: v.DurationValue.Seconds,
+			Days:    v.DurationValue.Days,
+			Months:  v.DurationValue.Months,
+		}
+	case *api_adapter_v1.AttributeValue_Int64Value:
+		return v.Int64Value
+	case *api_adapter_v1.AttributeValue_StringValue:
+		return v.StringValue
+	default:
+		return nil
+	}
+}
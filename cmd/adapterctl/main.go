@@ -0,0 +1,251 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command adapterctl sends GetPage requests to the adapter, to debug it without a gRPC client
+// such as Postman. The request is built from flags or from a JSON file, and sent either to an
+// adapter running in-process or to an adapter server over gRPC. The objects of each page are
+// printed as JSON or as a table.
+//
+//	adapterctl -entity users -attributes email,name,created_at:datetime -follow -output table
+//	adapterctl -request request.json -grpc-address localhost:8080 -grpc-token "$ADAPTER_TOKEN"
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/adapter"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/httprecord"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/logging"
+)
+
+// TokenEnv is the environment variable of the PagerDuty API key, if -token is not set.
+const TokenEnv = "PAGERDUTY_TOKEN"
+
+// options are the command-line options of adapterctl.
+type options struct {
+	requestFile   string
+	address       string
+	token         string
+	authorization string
+	entity        string
+	attributes    string
+	config        string
+	pageSize      int64
+	cursor        string
+	ordered       bool
+
+	follow   bool
+	maxPages int
+	output   string
+	verbose  bool
+	timeout  time.Duration
+
+	grpcAddress     string
+	grpcToken       string
+	grpcTLS         bool
+	grpcCAFile      string
+	grpcAdapterType string
+
+	record string
+	replay string
+}
+
+func main() {
+	if err := run(os.Args[0], os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(os.Stderr, "adapterctl: %v\n", err)
+		}
+
+		os.Exit(1)
+	}
+}
+
+func run(name string, args []string, stdout, stderr io.Writer) error {
+	opts, err := parseFlags(name, args, stderr)
+	if err != nil {
+		return err
+	}
+
+	request, err := buildRequest(opts, os.LookupEnv)
+	if err != nil {
+		return err
+	}
+
+	printer, err := newPrinter(opts.output, stdout, request)
+	if err != nil {
+		return err
+	}
+
+	// Failed requests are reported by adapterctl, so the adapter's warnings are only logged with -v.
+	level := slog.LevelError
+	if opts.verbose {
+		level = slog.LevelDebug
+	}
+
+	logger, err := logging.New(stderr, level, "text")
+	if err != nil {
+		return err
+	}
+
+	getPage, closeGetter, err := newPageGetter(opts, logger)
+	if err != nil {
+		return err
+	}
+
+	defer closeGetter()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	for page := 0; opts.maxPages <= 0 || page < opts.maxPages; page++ {
+		pageCtx, cancel := context.WithTimeout(ctx, opts.timeout)
+		response := getPage(pageCtx, request)
+		cancel()
+
+		if response.Error != nil {
+			return fmt.Errorf("page %d: %s: %s", page, response.Error.Code, response.Error.Message)
+		}
+
+		if err := printer.Print(page, response.Success); err != nil {
+			return fmt.Errorf("failed to print page %d: %w", page, err)
+		}
+
+		if !opts.follow || response.Success.NextCursor == "" {
+			return nil
+		}
+
+		request.Cursor = response.Success.NextCursor
+	}
+
+	fmt.Fprintf(stderr, "Stopped after %d pages, next cursor: %s\n", opts.maxPages, request.Cursor)
+
+	return nil
+}
+
+func parseFlags(name string, args []string, output io.Writer) (*options, error) {
+	opts := &options{}
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(output)
+
+	fs.StringVar(&opts.requestFile, "request", "", "The path of a JSON framework.Request, e.g. "+
+		`{"address": "api.pagerduty.com", "entityConfig": {"externalId": "users", "attributes": [{"externalId": "id", "type": 6}]}, "pageSize": 10}`+
+		". Other flags override its fields")
+	fs.StringVar(&opts.address, "address", "", `The address of the datasource (default "api.pagerduty.com")`)
+	fs.StringVar(&opts.token, "token", "", "The PagerDuty API key, sent as \"Token token=<key>\". Defaults to $"+TokenEnv)
+	fs.StringVar(&opts.authorization, "authorization", "", `The Authorization header sent to the datasource, e.g. "Bearer <OAuth token>". Overrides -token`)
+	fs.StringVar(&opts.entity, "entity", "", "The external ID of the entity, e.g. users")
+	fs.StringVar(&opts.attributes, "attributes", "", "Comma-separated external IDs of the attributes to return, optionally suffixed "+
+		"with :<type> (string, bool, datetime, double, duration, int64) and [] for lists, e.g. id,email,created_at:datetime,$.teams[*].id:string[]. "+
+		"The id attribute is always returned (default \"id\")")
+	fs.StringVar(&opts.config, "config", "", `The adapter config as JSON, e.g. {"since": "2023-01-01T00:00:00Z"}`)
+	fs.Int64Var(&opts.pageSize, "page-size", 0, "The page size (default the entity's maximum)")
+	fs.StringVar(&opts.cursor, "cursor", "", "The cursor of the first page to return")
+	fs.BoolVar(&opts.ordered, "ordered", false, "Request objects ordered by ID, which requires orderWithinPages in the config")
+
+	fs.BoolVar(&opts.follow, "follow", false, "Follow cursors until the entity is exhausted")
+	fs.IntVar(&opts.maxPages, "max-pages", 0, "The maximum number of pages to return with -follow, or 0 for no limit")
+	fs.StringVar(&opts.output, "output", "json", "The output format: json or table")
+	fs.BoolVar(&opts.verbose, "v", false, "Log the adapter's debug logs to stderr")
+	fs.DurationVar(&opts.timeout, "timeout", time.Minute, "The timeout of each GetPage call")
+
+	fs.StringVar(&opts.grpcAddress, "grpc-address", "", "The address of an adapter server to send requests to over gRPC, e.g. localhost:8080. "+
+		"If not set, the adapter runs in-process")
+	fs.StringVar(&opts.grpcToken, "grpc-token", "", "The token authenticating requests to the adapter server, one of its AUTH_TOKENS_PATH tokens")
+	fs.BoolVar(&opts.grpcTLS, "grpc-tls", false, "Connect to the adapter server over TLS")
+	fs.StringVar(&opts.grpcCAFile, "grpc-ca", "", "The path of the PEM encoded CA certificate of the adapter server. Implies -grpc-tls")
+	fs.StringVar(&opts.grpcAdapterType, "grpc-adapter-type", "Test-1.0.0", "The adapter type of requests sent over gRPC")
+
+	fs.StringVar(&opts.record, "record", "", "Record the datasource's sanitized responses to this cassette, see package httprecord. In-process only")
+	fs.StringVar(&opts.replay, "replay", "", "Replay the datasource's responses from this cassette instead of sending requests. In-process only")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+
+	switch {
+	case opts.output != "json" && opts.output != "table":
+		return nil, fmt.Errorf("invalid -output %q, must be json or table", opts.output)
+	case opts.record != "" && opts.replay != "":
+		return nil, errors.New("-record and -replay are mutually exclusive")
+	case opts.grpcAddress != "" && (opts.record != "" || opts.replay != ""):
+		return nil, errors.New("-record and -replay are only supported in-process, without -grpc-address")
+	case opts.timeout <= 0:
+		return nil, errors.New("-timeout must be positive")
+	}
+
+	return opts, nil
+}
+
+// pageGetter returns a page of objects.
+type pageGetter func(ctx context.Context, request *framework.Request[adapter.Config]) framework.Response
+
+// newPageGetter returns a pageGetter that calls the adapter in-process, or over gRPC if
+// -grpc-address is set, and a function that releases its resources.
+func newPageGetter(opts *options, logger *slog.Logger) (pageGetter, func(), error) {
+	if opts.grpcAddress != "" {
+		return newGRPCPageGetter(opts)
+	}
+
+	var clientOpts []adapter.ClientOption
+
+	var recorder *httprecord.Recorder
+
+	if opts.record != "" || opts.replay != "" {
+		path, mode := opts.replay, httprecord.ModeReplay
+		if opts.record != "" {
+			path, mode = opts.record, httprecord.ModeRecord
+		}
+
+		var err error
+		if recorder, err = httprecord.New(path, mode); err != nil {
+			return nil, nil, err
+		}
+
+		clientOpts = append(clientOpts, adapter.WithTransport(recorder))
+	}
+
+	a := adapter.NewAdapter(newClient(opts, logger, clientOpts...), logger)
+
+	closeGetter := func() {
+		if recorder == nil {
+			return
+		}
+
+		if err := recorder.Save(); err != nil {
+			logger.Error("Failed to save cassette", slog.Any("error", err))
+		}
+	}
+
+	return a.GetPage, closeGetter, nil
+}
+
+// newClient returns the client of the in-process adapter, which times out with the GetPage call.
+func newClient(opts *options, logger *slog.Logger, clientOpts ...adapter.ClientOption) adapter.Client {
+	return adapter.NewClient(0, logger, append(clientOpts, adapter.WithClientTimeout(opts.timeout))...)
+}
@@ -0,0 +1,103 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tksarunachalam/sgnl-adapter/pkg/adapter"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/logging"
+)
+
+func TestParseFlags(t *testing.T) {
+	tests := map[string]struct {
+		args []string
+
+		want    *options
+		wantErr string
+	}{
+		"defaults": {
+			want: &options{output: "json", timeout: time.Minute, grpcAdapterType: "Test-1.0.0"},
+		},
+		"sub_second_timeout": {
+			args: []string{"-entity", "users", "-follow", "-output", "table", "-timeout", "500ms"},
+			want: &options{entity: "users", follow: true, output: "table", timeout: 500 * time.Millisecond, grpcAdapterType: "Test-1.0.0"},
+		},
+		"unknown_flag": {
+			args:    []string{"-entities", "users"},
+			wantErr: "flag provided but not defined: -entities",
+		},
+		"unexpected_arguments": {
+			args:    []string{"-entity", "users", "teams"},
+			wantErr: "unexpected arguments: [teams]",
+		},
+		"invalid_output": {
+			args:    []string{"-output", "yaml"},
+			wantErr: `invalid -output "yaml", must be json or table`,
+		},
+		"record_and_replay": {
+			args:    []string{"-record", "a.json", "-replay", "b.json"},
+			wantErr: "-record and -replay are mutually exclusive",
+		},
+		"grpc_and_replay": {
+			args:    []string{"-grpc-address", "localhost:8080", "-replay", "b.json"},
+			wantErr: "-record and -replay are only supported in-process, without -grpc-address",
+		},
+		"zero_timeout": {
+			args:    []string{"-timeout", "0s"},
+			wantErr: "-timeout must be positive",
+		},
+		"negative_timeout": {
+			args:    []string{"-timeout", "-1s"},
+			wantErr: "-timeout must be positive",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := parseFlags("adapterctl", tt.args, io.Discard)
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Failed to parse flags: %v", err)
+			}
+
+			if *got != *tt.want {
+				t.Errorf("got options %+v, want %+v", *got, *tt.want)
+			}
+		})
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	for _, timeout := range []time.Duration{500 * time.Millisecond, 1500 * time.Millisecond, time.Minute} {
+		t.Run(timeout.String(), func(t *testing.T) {
+			client := newClient(&options{timeout: timeout}, logging.Discard())
+
+			if got := client.(*adapter.Datasource).Client.Timeout; got != timeout {
+				t.Errorf("got client timeout %s, want %s", got, timeout)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/adapter"
+)
+
+// maxCellWidth is the maximum number of characters of a table cell, beyond which values are
+// truncated.
+const maxCellWidth = 60
+
+// printer prints pages of objects.
+type printer interface {
+	// Print prints the page at the index, from 0.
+	Print(index int, page *framework.Page) error
+}
+
+func newPrinter(format string, w io.Writer, request *framework.Request[adapter.Config]) (printer, error) {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+
+		return &jsonPrinter{encoder: encoder}, nil
+	case "table":
+		return newTablePrinter(w, &request.Entity), nil
+	default:
+		return nil, fmt.Errorf("unknown output format %q", format)
+	}
+}
+
+// jsonPrinter prints each page as a JSON document.
+type jsonPrinter struct {
+	encoder *json.Encoder
+}
+
+type jsonPage struct {
+	Page       int                `json:"page"`
+	Objects    []framework.Object `json:"objects"`
+	NextCursor string             `json:"nextCursor,omitempty"`
+}
+
+func (p *jsonPrinter) Print(index int, page *framework.Page) error {
+	objects := page.Objects
+	if objects == nil {
+		objects = []framework.Object{}
+	}
+
+	return p.encoder.Encode(jsonPage{Page: index, Objects: objects, NextCursor: page.NextCursor})
+}
+
+// tablePrinter prints each page as a table with a column per requested attribute and child
+// entity. Child entities are summarized by their number of objects.
+type tablePrinter struct {
+	w       io.Writer
+	columns []string
+}
+
+func newTablePrinter(w io.Writer, entity *framework.EntityConfig) *tablePrinter {
+	p := &tablePrinter{w: w}
+
+	for _, attribute := range entity.Attributes {
+		p.columns = append(p.columns, attribute.ExternalId)
+	}
+
+	for _, child := range entity.ChildEntities {
+		p.columns = append(p.columns, child.ExternalId)
+	}
+
+	return p
+}
+
+func (p *tablePrinter) Print(index int, page *framework.Page) error {
+	if index > 0 {
+		fmt.Fprintln(p.w)
+	}
+
+	fmt.Fprintf(p.w, "Page %d: %d objects\n", index, len(page.Objects))
+
+	// Columns are aligned within a page, for pages to be printed as soon as they are returned.
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, strings.ToUpper(strings.Join(p.columns, "\t")))
+
+	for _, object := range page.Objects {
+		cells := make([]string, 0, len(p.columns))
+		for _, column := range p.columns {
+			cells = append(cells, cell(object[column]))
+		}
+
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if page.NextCursor != "" {
+		fmt.Fprintf(p.w, "Next cursor: %s\n", page.NextCursor)
+	}
+
+	return nil
+}
+
+// cell formats an attribute value or child objects as a table cell.
+func cell(value any) string {
+	var s string
+
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []framework.Object:
+		return fmt.Sprintf("<%d objects>", len(v))
+	case time.Time:
+		s = v.Format(time.RFC3339)
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			values = append(values, cell(item))
+		}
+
+		s = "[" + strings.Join(values, ", ") + "]"
+	case string:
+		s = v
+	default:
+		if raw, err := json.Marshal(v); err == nil {
+			s = string(raw)
+		} else {
+			s = fmt.Sprint(v)
+		}
+	}
+
+	// Tabs and line breaks would break the table's alignment.
+	s = strings.Join(strings.Fields(s), " ")
+
+	if runes := []rune(s); len(runes) > maxCellWidth {
+		s = string(runes[:maxCellWidth-1]) + "…"
+	}
+
+	return s
+}
@@ -0,0 +1,177 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/adapter"
+)
+
+// DefaultAddress is the address of the datasource, unless set by -address or the request file.
+const DefaultAddress = "api.pagerduty.com"
+
+// attributeTypes are the attribute types of -attributes, by name.
+var attributeTypes = map[string]framework.AttributeType{
+	"string":   framework.AttributeTypeString,
+	"bool":     framework.AttributeTypeBool,
+	"datetime": framework.AttributeTypeDateTime,
+	"double":   framework.AttributeTypeDouble,
+	"duration": framework.AttributeTypeDuration,
+	"int64":    framework.AttributeTypeInt64,
+}
+
+// buildRequest returns the request read from -request, if set, whose fields are overridden by the
+// other flags.
+func buildRequest(opts *options, lookupEnv func(string) (string, bool)) (*framework.Request[adapter.Config], error) {
+	request := &framework.Request[adapter.Config]{}
+
+	if opts.requestFile != "" {
+		data, err := os.ReadFile(opts.requestFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request file: %w", err)
+		}
+
+		if err := decodeStrict(data, request); err != nil {
+			return nil, fmt.Errorf("failed to parse request file %s: %w", opts.requestFile, err)
+		}
+	}
+
+	if opts.address != "" {
+		request.Address = opts.address
+	}
+
+	if request.Address == "" {
+		request.Address = DefaultAddress
+	}
+
+	token := opts.token
+	if token == "" && request.Auth == nil {
+		token, _ = lookupEnv(TokenEnv)
+	}
+
+	switch {
+	case opts.authorization != "":
+		request.Auth = &framework.DatasourceAuthCredentials{HTTPAuthorization: opts.authorization}
+	case token != "":
+		request.Auth = &framework.DatasourceAuthCredentials{HTTPAuthorization: "Token token=" + token}
+	case request.Auth == nil && opts.replay != "":
+		// Replayed requests are not sent, so they need no credentials.
+		request.Auth = &framework.DatasourceAuthCredentials{HTTPAuthorization: "Token token=replay"}
+	case request.Auth == nil:
+		return nil, fmt.Errorf("no datasource credentials, set -token, -authorization or $%s", TokenEnv)
+	}
+
+	if opts.entity != "" {
+		request.Entity.ExternalId = opts.entity
+	}
+
+	if request.Entity.ExternalId == "" {
+		return nil, errors.New("no entity, set -entity or entityConfig.externalId in the request file")
+	}
+
+	if opts.attributes != "" || len(request.Entity.Attributes) == 0 {
+		attributes, err := parseAttributes(opts.attributes)
+		if err != nil {
+			return nil, err
+		}
+
+		request.Entity.Attributes = attributes
+	}
+
+	if opts.config != "" {
+		request.Config = &adapter.Config{}
+
+		if err := decodeStrict([]byte(opts.config), request.Config); err != nil {
+			return nil, fmt.Errorf("failed to parse -config: %w", err)
+		}
+	}
+
+	// The adapter requires a config, even an empty one.
+	if request.Config == nil {
+		request.Config = &adapter.Config{}
+	}
+
+	if opts.pageSize != 0 {
+		request.PageSize = opts.pageSize
+	}
+
+	if request.PageSize == 0 {
+		request.PageSize = adapter.MaxPageSize
+		if entity, found := adapter.ValidEntityExternalIDs[request.Entity.ExternalId]; found {
+			request.PageSize = entity.MaxPageSize()
+		}
+	}
+
+	if opts.cursor != "" {
+		request.Cursor = opts.cursor
+	}
+
+	request.Ordered = request.Ordered || opts.ordered
+
+	return request, nil
+}
+
+// parseAttributes parses the comma-separated attributes of -attributes. The id attribute is
+// always included, first.
+func parseAttributes(value string) ([]*framework.AttributeConfig, error) {
+	attributes := []*framework.AttributeConfig{{ExternalId: "id", Type: framework.AttributeTypeString}}
+
+	for _, spec := range strings.Split(value, ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+
+		attribute := &framework.AttributeConfig{ExternalId: spec, Type: framework.AttributeTypeString}
+
+		// JSONPath attribute names may contain colons, so only a known type after the last one is
+		// parsed as the attribute's type.
+		if i := strings.LastIndex(spec, ":"); i >= 0 {
+			typeName, list := strings.CutSuffix(spec[i+1:], "[]")
+
+			if attributeType, found := attributeTypes[typeName]; found {
+				attribute.ExternalId = spec[:i]
+				attribute.Type = attributeType
+				attribute.List = list
+			}
+		}
+
+		switch attribute.ExternalId {
+		case "":
+			return nil, fmt.Errorf("invalid attribute %q in -attributes", spec)
+		case "id":
+			continue
+		}
+
+		attributes = append(attributes, attribute)
+	}
+
+	return attributes, nil
+}
+
+// decodeStrict unmarshals JSON data into v, rejecting unknown fields.
+func decodeStrict(data []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	return decoder.Decode(v)
+}
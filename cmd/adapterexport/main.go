@@ -0,0 +1,241 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command adapterexport pages through every object of the adapter's entities, as SGNL would,
+// and writes them to NDJSON or CSV files with a manifest of their counts, see package export.
+// The attributes of the entities are read from a template, as emitted by discoverschema, or
+// discovered before the export.
+//
+//	adapterexport -template entities.yaml -out export
+//	adapterexport -template entities.yaml -out export -resume
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/adapter"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/export"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/logging"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/schema"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/serverconfig"
+)
+
+// TokenEnv is the environment variable of the PagerDuty API key, if -token is not set.
+const TokenEnv = "PAGERDUTY_TOKEN"
+
+func main() {
+	if err := run(os.Args[0], os.Args[1:], os.Stderr); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(os.Stderr, "adapterexport: %v\n", err)
+		}
+
+		os.Exit(1)
+	}
+}
+
+func run(name string, args []string, stderr io.Writer) error {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	address := fs.String("address", "api.pagerduty.com", "The address of the datasource")
+	token := fs.String("token", "", "The PagerDuty API key, sent as \"Token token=<key>\". Defaults to $"+TokenEnv)
+	authorization := fs.String("authorization", "", `The Authorization header sent to the datasource, e.g. "Bearer <OAuth token>". Overrides -token`)
+	templatePath := fs.String("template", "", "The path of the template of the exported entities, as emitted by discoverschema (default discover the entities first)")
+	entities := fs.String("entities", "", "Comma-separated external IDs of the entities to export (default every entity of the template)")
+	format := fs.String("format", string(export.FormatNDJSON), "The format of the exported files: ndjson or csv")
+	out := fs.String("out", "export", "The export directory")
+	resume := fs.Bool("resume", false, "Resume the export in the export directory from its last checkpoint")
+	pageSize := fs.Int64("page-size", 0, "The size of the requested pages (default the maximum of each entity)")
+	configJSON := fs.String("config", "", "The JSON adapter config of the requests. Overrides the template's adapterConfig")
+	timeout := fs.Duration("timeout", time.Hour, "The timeout of the export")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	switch {
+	case fs.NArg() > 0:
+		return fmt.Errorf("unexpected arguments: %v", fs.Args())
+	case *format != string(export.FormatNDJSON) && *format != string(export.FormatCSV):
+		return fmt.Errorf("invalid -format %q, must be ndjson or csv", *format)
+	}
+
+	auth := *authorization
+	if auth == "" {
+		key := *token
+		if key == "" {
+			key = os.Getenv(TokenEnv)
+		}
+
+		if key == "" {
+			return fmt.Errorf("no datasource credentials, set -token, -authorization or $%s", TokenEnv)
+		}
+
+		auth = "Token token=" + key
+	}
+
+	// Requests are sent as by the adapter server with its default configuration, without retries.
+	defaults := serverconfig.Default()
+
+	client := adapter.NewClient(int(defaults.Timeouts.HTTPClient/time.Second), logging.Discard(),
+		adapter.WithRequestTimeout(defaults.Timeouts.Request),
+		adapter.WithRetryPolicy(adapter.RetryPolicy{
+			MaxAttempts:    defaults.Retry.MaxAttempts,
+			InitialBackoff: defaults.Retry.InitialBackoff,
+			MaxBackoff:     defaults.Retry.MaxBackoff,
+		}),
+	)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+
+	var filter []string
+	if *entities != "" {
+		filter = strings.Split(*entities, ",")
+	}
+
+	template, err := loadTemplate(ctx, client, *templatePath, *address, auth, filter, *pageSize)
+	if err != nil {
+		return err
+	}
+
+	config, err := template.Config()
+	if err != nil {
+		return err
+	}
+
+	if *configJSON != "" {
+		config = &adapter.Config{}
+		if err := json.Unmarshal([]byte(*configJSON), config); err != nil {
+			return fmt.Errorf("invalid -config: %w", err)
+		}
+	}
+
+	exported, err := exportEntities(template, filter)
+	if err != nil {
+		return err
+	}
+
+	a := &adapter.Adapter{Client: client, Logger: logging.Discard()}
+
+	manifest, err := export.Export(ctx, a, exported, export.Options{
+		Dir:      *out,
+		Format:   export.Format(*format),
+		Address:  *address,
+		Auth:     &framework.DatasourceAuthCredentials{HTTPAuthorization: auth},
+		Config:   config,
+		PageSize: *pageSize,
+		Resume:   *resume,
+		Progress: func(p export.Progress) {
+			status := "in progress"
+			if p.Done {
+				status = "done"
+			}
+
+			fmt.Fprintf(stderr, "%s: %d objects in %d pages (%s, %s)\n",
+				p.Entity, p.Objects, p.Pages, p.Elapsed.Round(time.Millisecond), status)
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	total := 0
+	for _, entity := range manifest.Entities {
+		total += entity.Objects
+	}
+
+	fmt.Fprintf(stderr, "Exported %d objects of %d entities to %s\n", total, len(manifest.Entities), *out)
+
+	return nil
+}
+
+// loadTemplate reads the template at path, or discovers the entities if path is empty.
+func loadTemplate(
+	ctx context.Context, client adapter.Client, path, address, auth string, entities []string, pageSize int64,
+) (*schema.Template, error) {
+	if path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+
+		defer f.Close()
+
+		return schema.ReadTemplate(f)
+	}
+
+	baseURL := address
+	if !strings.HasPrefix(baseURL, "https://") {
+		baseURL = "https://" + baseURL
+	}
+
+	return schema.Discover(ctx, client, schema.Options{
+		BaseURL:  baseURL,
+		Token:    auth,
+		Entities: entities,
+		PageSize: pageSize,
+		MaxDepth: schema.DefaultMaxDepth,
+	})
+}
+
+// exportEntities returns the entities of the template to export, sorted by external ID, or only
+// those of the filter if set.
+func exportEntities(template *schema.Template, filter []string) ([]export.Entity, error) {
+	configs := make(map[string]schema.EntityConfig, len(template.Entities))
+	for _, config := range template.Entities {
+		configs[config.ExternalID] = config
+	}
+
+	if len(filter) == 0 {
+		for externalID := range configs {
+			filter = append(filter, externalID)
+		}
+
+		sort.Strings(filter)
+	}
+
+	entities := make([]export.Entity, 0, len(filter))
+
+	for _, externalID := range filter {
+		config, found := configs[externalID]
+		if !found {
+			return nil, fmt.Errorf("entity %q is not in the template", externalID)
+		}
+
+		attributes, err := config.FrameworkAttributes()
+		if err != nil {
+			return nil, err
+		}
+
+		entities = append(entities, export.Entity{ExternalID: externalID, Attributes: attributes})
+	}
+
+	return entities, nil
+}
@@ -0,0 +1,202 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command discoverschema samples pages of the adapter's entities from PagerDuty, infers their
+// attributes and prints them as the entities of a SGNL system of record template, see package
+// schema.
+//
+//	discoverschema -entities users,teams -pages 3 > entities.yaml
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/tksarunachalam/sgnl-adapter/pkg/adapter"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/httprecord"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/logging"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/schema"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/serverconfig"
+	"gopkg.in/yaml.v3"
+)
+
+// TokenEnv is the environment variable of the PagerDuty API key, if -token is not set.
+const TokenEnv = "PAGERDUTY_TOKEN"
+
+func main() {
+	if err := run(os.Args[0], os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(os.Stderr, "discoverschema: %v\n", err)
+		}
+
+		os.Exit(1)
+	}
+}
+
+func run(name string, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	address := fs.String("address", "api.pagerduty.com", "The address of the datasource")
+	token := fs.String("token", "", "The PagerDuty API key, sent as \"Token token=<key>\". Defaults to $"+TokenEnv)
+	authorization := fs.String("authorization", "", `The Authorization header sent to the datasource, e.g. "Bearer <OAuth token>". Overrides -token`)
+	entities := fs.String("entities", "", "Comma-separated external IDs of the entities to discover (default every entity)")
+	pages := fs.Int("pages", schema.DefaultPages, "The maximum number of pages sampled per entity")
+	pageSize := fs.Int64("page-size", 0, "The size of the sampled pages (default the maximum of each entity)")
+	maxDepth := fs.Int("max-depth", schema.DefaultMaxDepth, "The depth of the nested objects whose fields are mapped as JSONPath attributes, or 0 for top-level fields only")
+	format := fs.String("format", "yaml", "The output format: yaml or json")
+	output := fs.String("o", "", "The path of the output file (default stdout)")
+	timeout := fs.Duration("timeout", 5*time.Minute, "The timeout of the discovery")
+	record := fs.String("record", "", "Record the datasource's sanitized responses to this cassette, see package httprecord")
+	replay := fs.String("replay", "", "Replay the datasource's responses from this cassette instead of sending requests")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	switch {
+	case fs.NArg() > 0:
+		return fmt.Errorf("unexpected arguments: %v", fs.Args())
+	case *format != "yaml" && *format != "json":
+		return fmt.Errorf("invalid -format %q, must be yaml or json", *format)
+	case *record != "" && *replay != "":
+		return errors.New("-record and -replay are mutually exclusive")
+	case *timeout <= 0:
+		return errors.New("-timeout must be positive")
+	}
+
+	auth := *authorization
+	if auth == "" {
+		key := *token
+		if key == "" {
+			key = os.Getenv(TokenEnv)
+		}
+
+		switch {
+		case key != "":
+			auth = "Token token=" + key
+		case *replay != "":
+			// Replayed requests are not sent, so they need no credentials.
+			auth = "Token token=replay"
+		default:
+			return fmt.Errorf("no datasource credentials, set -token, -authorization or $%s", TokenEnv)
+		}
+	}
+
+	// Requests are sent as by the adapter server with its default configuration, without retries.
+	defaults := serverconfig.Default()
+
+	clientOpts := []adapter.ClientOption{
+		adapter.WithRequestTimeout(defaults.Timeouts.Request),
+		adapter.WithRetryPolicy(adapter.RetryPolicy{
+			MaxAttempts:    defaults.Retry.MaxAttempts,
+			InitialBackoff: defaults.Retry.InitialBackoff,
+			MaxBackoff:     defaults.Retry.MaxBackoff,
+		}),
+		adapter.WithClientTimeout(defaults.Timeouts.HTTPClient),
+	}
+
+	var recorder *httprecord.Recorder
+
+	if *record != "" || *replay != "" {
+		path, mode := *replay, httprecord.ModeReplay
+		if *record != "" {
+			path, mode = *record, httprecord.ModeRecord
+		}
+
+		var err error
+		if recorder, err = httprecord.New(path, mode); err != nil {
+			return err
+		}
+
+		clientOpts = append(clientOpts, adapter.WithTransport(recorder))
+	}
+
+	client := adapter.NewClient(0, logging.Discard(), clientOpts...)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+
+	baseURL := *address
+	if !strings.HasPrefix(baseURL, "https://") {
+		baseURL = "https://" + baseURL
+	}
+
+	opts := schema.Options{
+		BaseURL:  baseURL,
+		Token:    auth,
+		Pages:    *pages,
+		PageSize: *pageSize,
+		MaxDepth: *maxDepth,
+	}
+
+	if *entities != "" {
+		opts.Entities = strings.Split(*entities, ",")
+	}
+
+	template, discoverErr := schema.Discover(ctx, client, opts)
+	if template == nil {
+		return discoverErr
+	}
+
+	if recorder != nil {
+		if err := recorder.Save(); err != nil {
+			return err
+		}
+	}
+
+	var buf bytes.Buffer
+
+	var err error
+
+	if *format == "json" {
+		encoder := json.NewEncoder(&buf)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(template)
+	} else {
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		err = encoder.Encode(template)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to marshal template: %w", err)
+	}
+
+	if *output == "" {
+		_, err = stdout.Write(buf.Bytes())
+	} else {
+		err = os.WriteFile(*output, buf.Bytes(), 0o644)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to write template: %w", err)
+	}
+
+	// The entities that could be sampled are written even if others failed.
+	return discoverErr
+}
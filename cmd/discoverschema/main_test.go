@@ -0,0 +1,136 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tksarunachalam/sgnl-adapter/pkg/adapter"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/httprecord"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/pagerdutymock"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/schema"
+)
+
+func TestRunFlags(t *testing.T) {
+	t.Setenv(TokenEnv, "")
+
+	tests := map[string]struct {
+		args    []string
+		wantErr string
+	}{
+		"unknown_flag": {
+			args:    []string{"-entity", "users"},
+			wantErr: "flag provided but not defined: -entity",
+		},
+		"unexpected_arguments": {
+			args:    []string{"-entities", "users", "teams"},
+			wantErr: "unexpected arguments: [teams]",
+		},
+		"invalid_format": {
+			args:    []string{"-format", "csv"},
+			wantErr: `invalid -format "csv", must be yaml or json`,
+		},
+		"record_and_replay": {
+			args:    []string{"-record", "a.json", "-replay", "b.json"},
+			wantErr: "-record and -replay are mutually exclusive",
+		},
+		"zero_timeout": {
+			args:    []string{"-timeout", "0s"},
+			wantErr: "-timeout must be positive",
+		},
+		"no_credentials": {
+			args:    []string{"-entities", "users"},
+			wantErr: "no datasource credentials, set -token, -authorization or $" + TokenEnv,
+		},
+		"missing_cassette": {
+			args:    []string{"-replay", filepath.Join(t.TempDir(), "missing.json")},
+			wantErr: "missing.json",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := run("discoverschema", tt.args, io.Discard, io.Discard)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	// Record the requests that discoverschema sends, from the fake PagerDuty API.
+	mock := pagerdutymock.NewTestServer(t, pagerdutymock.DefaultFixtures(1))
+
+	recorder, err := httprecord.New(path, httprecord.ModeRecord)
+	if err != nil {
+		t.Fatalf("Failed to create recorder: %v", err)
+	}
+
+	recorder.Transport = mock.Client.Transport
+
+	_, err = schema.Discover(context.Background(), adapter.NewClient(10, nil, adapter.WithTransport(recorder)), schema.Options{
+		BaseURL:  mock.URL,
+		Token:    "Token token=test",
+		Entities: []string{adapter.Users, adapter.Teams},
+		Pages:    1,
+		MaxDepth: schema.DefaultMaxDepth,
+	})
+	if err != nil {
+		t.Fatalf("Failed to discover entities: %v", err)
+	}
+
+	if err := recorder.Save(); err != nil {
+		t.Fatalf("Failed to save cassette: %v", err)
+	}
+
+	var stdout bytes.Buffer
+
+	args := []string{"-replay", path, "-entities", "users,teams", "-pages", "1", "-format", "json", "-timeout", "10s"}
+	if err := run("discoverschema", args, &stdout, io.Discard); err != nil {
+		t.Fatalf("Failed to run: %v", err)
+	}
+
+	var template schema.Template
+	if err := json.Unmarshal(stdout.Bytes(), &template); err != nil {
+		t.Fatalf("Failed to parse template: %v\n%s", err, stdout.String())
+	}
+
+	// The entities are keyed by their names, e.g. User.
+	configs := make(map[string]schema.EntityConfig, len(template.Entities))
+	for _, config := range template.Entities {
+		configs[config.ExternalID] = config
+	}
+
+	for _, entity := range []string{adapter.Users, adapter.Teams} {
+		config, ok := configs[entity]
+		if !ok {
+			t.Errorf("got no %s entity in template %s", entity, stdout.String())
+
+			continue
+		}
+
+		if len(config.Attributes) == 0 || !config.Attributes[0].UniqueID {
+			t.Errorf("got %s attributes %+v, want the unique ID first", entity, config.Attributes)
+		}
+	}
+}
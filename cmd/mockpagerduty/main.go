@@ -0,0 +1,232 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command mockpagerduty serves a mock of the PagerDuty REST API, to run the adapter locally
+// without a PagerDuty account. See package pagerdutymock.
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/tksarunachalam/sgnl-adapter/pkg/pagerdutymock"
+)
+
+var (
+	// Port is the port at which the mock server listens.
+	Port = flag.Int("port", 8443, "The server port")
+
+	// Seed is the seed of the generated fixtures.
+	Seed = flag.Int64("seed", 1, "The seed of the generated fixtures")
+
+	// FixturesFile is the path of a JSON file of fixtures, which replace the generated fixtures of
+	// the collections it contains.
+	FixturesFile = flag.String("fixtures", "", "The path of a JSON file of fixtures that replace the generated collections")
+
+	// Token is the API key accepted by the mock server. If empty, any token is accepted.
+	Token = flag.String("token", "", "The API key or OAuth token accepted by the server. If not set, any token is accepted")
+
+	// TLSCertFile and TLSKeyFile are the server certificate and key. If not set, a self-signed
+	// certificate is generated.
+	TLSCertFile = flag.String("tls-cert", "", "The path of the PEM encoded server certificate. If not set, a self-signed certificate is generated")
+	TLSKeyFile  = flag.String("tls-key", "", "The path of the PEM encoded server private key")
+
+	// SelfSignedCertFile is the path the generated self-signed certificate is written to, for
+	// clients to trust it (e.g. with the SSL_CERT_FILE environment variable).
+	SelfSignedCertFile = flag.String("self-signed-cert-out", "mockpagerduty.pem", "The path the generated self-signed certificate is written to")
+
+	// Plaintext serves HTTP instead of HTTPS.
+	Plaintext = flag.Bool("plaintext", false, "Serve HTTP instead of HTTPS")
+
+	// RateLimitProbability is the probability of a 429 response.
+	RateLimitProbability = flag.Float64("rate-limit-probability", 0, "The probability of a 429 Too Many Requests response")
+
+	// RetryAfter is the Retry-After header of 429 responses, in seconds.
+	RetryAfter = flag.Int("retry-after", 1, "The Retry-After header of 429 responses (seconds)")
+
+	// ErrorProbability is the probability of an ErrorStatus response.
+	ErrorProbability = flag.Float64("error-probability", 0, "The probability of an -error-status response")
+
+	// ErrorStatus is the status code of injected errors.
+	ErrorStatus = flag.Int("error-status", http.StatusServiceUnavailable, "The status code of injected errors")
+
+	// Delay is the delay of slow responses.
+	Delay = flag.Duration("delay", 0, "The delay of slow responses")
+
+	// DelayProbability is the probability of a slow response.
+	DelayProbability = flag.Float64("delay-probability", 1, "The probability of a slow response, if -delay is set")
+)
+
+func main() {
+	flag.Parse()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+	fixtures := pagerdutymock.DefaultFixtures(*Seed)
+
+	if *FixturesFile != "" {
+		f, err := os.Open(*FixturesFile)
+		if err != nil {
+			fatal(logger, "Failed to open fixtures file", slog.Any("error", err))
+		}
+
+		loaded, err := pagerdutymock.LoadFixtures(f)
+		f.Close()
+
+		if err != nil {
+			fatal(logger, "Failed to load fixtures", slog.Any("error", err))
+		}
+
+		for collection, objects := range loaded {
+			fixtures[collection] = objects
+		}
+	}
+
+	mock := pagerdutymock.New(fixtures)
+	mock.Token = *Token
+
+	if *RateLimitProbability > 0 {
+		mock.AddFault(pagerdutymock.Fault{
+			Status:      http.StatusTooManyRequests,
+			RetryAfter:  *RetryAfter,
+			Probability: *RateLimitProbability,
+		})
+	}
+
+	if *ErrorProbability > 0 {
+		mock.AddFault(pagerdutymock.Fault{Status: *ErrorStatus, Probability: *ErrorProbability})
+	}
+
+	if *Delay > 0 {
+		mock.AddFault(pagerdutymock.Fault{Delay: *Delay, Probability: *DelayProbability})
+	}
+
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%d", *Port),
+		Handler:           logRequests(logger, mock),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	var err error
+
+	switch {
+	case *Plaintext:
+		logger.Info("Serving mock PagerDuty API over HTTP", slog.Int("port", *Port))
+		err = server.ListenAndServe()
+	case *TLSCertFile != "":
+		logger.Info("Serving mock PagerDuty API over HTTPS", slog.Int("port", *Port))
+		err = server.ListenAndServeTLS(*TLSCertFile, *TLSKeyFile)
+	default:
+		cert, certErr := selfSignedCertificate(*SelfSignedCertFile)
+		if certErr != nil {
+			fatal(logger, "Failed to generate self-signed certificate", slog.Any("error", certErr))
+		}
+
+		server.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+
+		logger.Info("Serving mock PagerDuty API over HTTPS with a self-signed certificate",
+			slog.Int("port", *Port), slog.String("certificate", *SelfSignedCertFile))
+
+		err = server.ListenAndServeTLS("", "")
+	}
+
+	if err != nil {
+		fatal(logger, "Failed to serve", slog.Any("error", err))
+	}
+}
+
+// logRequests logs every request and the status of its response.
+func logRequests(logger *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+
+		next.ServeHTTP(recorder, r)
+
+		logger.Info("Served request",
+			slog.String("method", r.Method),
+			slog.String("url", r.URL.String()),
+			slog.Int("status", recorder.status),
+			slog.Duration("duration", time.Since(start)),
+		)
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// selfSignedCertificate generates a certificate for localhost, valid for a day, and writes it
+// to path in PEM format.
+func selfSignedCertificate(path string) (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "mockpagerduty"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	if err := os.WriteFile(path, certPEM, 0o644); err != nil {
+		return tls.Certificate{}, err
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
+
+// fatal logs the error and exits.
+func fatal(logger *slog.Logger, msg string, args ...any) {
+	logger.Error(msg, args...)
+	os.Exit(1)
+}
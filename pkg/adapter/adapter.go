@@ -17,12 +17,16 @@ package adapter
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"strings"
 	"time"
 
 	framework "github.com/sgnl-ai/adapter-framework"
 	api_adapter_v1 "github.com/sgnl-ai/adapter-framework/api/adapter/v1"
 	"github.com/sgnl-ai/adapter-framework/web"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/logging"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Adapter implements the framework.Adapter interface to query pages of objects
@@ -32,27 +36,142 @@ type Adapter struct {
 
 	// Client provides access to the datasource.
 	Client Client
+
+	// Logger logs the requests to the adapter.
+	Logger *slog.Logger
+
+	// Entities are the external IDs of the entities served by the adapter.
+	// If nil, every entity in ValidEntityExternalIDs is served.
+	Entities map[string]struct{}
+
+	// DefaultConfig is the config applied to the fields left unset in the config of requests.
+	// If nil, requests are served with their own config only.
+	DefaultConfig *Config
+
+	// Watermarks stores the high-water marks of completed incremental syncs, from which the next
+	// syncs start. If nil, every sync starts from the watermark configured in the since field.
+	Watermarks Watermarks
+}
+
+// Option configures an Adapter.
+type Option func(*Adapter)
+
+// WithEntities restricts the entities served by the adapter to the given external IDs.
+// Requests for other entities are rejected.
+func WithEntities(entityExternalIDs ...string) Option {
+	return func(a *Adapter) {
+		a.Entities = make(map[string]struct{}, len(entityExternalIDs))
+
+		for _, id := range entityExternalIDs {
+			a.Entities[id] = struct{}{}
+		}
+	}
+}
+
+// WithDefaultConfig sets the config applied to the fields left unset in the config of requests.
+// This allows several adapter types to serve the same entities with different defaults.
+func WithDefaultConfig(config *Config) Option {
+	return func(a *Adapter) {
+		a.DefaultConfig = config
+	}
+}
+
+// WithWatermarks sets the store of the high-water marks of incremental syncs, e.g. to share them
+// between several adapters or to persist them across restarts.
+func WithWatermarks(watermarks Watermarks) Option {
+	return func(a *Adapter) {
+		a.Watermarks = watermarks
+	}
 }
 
 // NewAdapter instantiates a new Adapter.
+// If logger is nil, logs are discarded. High-water marks of incremental syncs are kept in memory,
+// unless set by WithWatermarks.
 //
 // SCAFFOLDING #21 - pkg/adapter/adapter.go: Add or remove parameters to match field updates above.
-func NewAdapter(client Client) framework.Adapter[Config] {
-	return &Adapter{
-		Client: client,
+func NewAdapter(client Client, logger *slog.Logger, opts ...Option) framework.Adapter[Config] {
+	if logger == nil {
+		logger = logging.Discard()
+	}
+
+	a := &Adapter{
+		Client:     client,
+		Logger:     logger,
+		Watermarks: NewMemoryWatermarks(),
 	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	return a
+}
+
+// servesEntity returns true if the adapter serves the entity, assumed to be valid.
+func (a *Adapter) servesEntity(entityExternalID string) bool {
+	if a.Entities == nil {
+		return true
+	}
+
+	_, found := a.Entities[entityExternalID]
+
+	return found
 }
 
 // GetPage is called by SGNL's ingestion service to query a page of objects
 // from a datasource.
-func (a *Adapter) GetPage(ctx context.Context, request *framework.Request[Config]) framework.Response {
-	if err := a.ValidateGetPageRequest(ctx, request); err != nil {
+func (a *Adapter) GetPage(ctx context.Context, request *framework.Request[Config]) (response framework.Response) {
+	defer observeGetPage(request.Entity.ExternalId, time.Now(), &response)
+
+	ctx, span := tracer.Start(ctx, "Adapter.GetPage", trace.WithAttributes(
+		entityAttribute(request.Entity.ExternalId),
+		attribute.Int64("sgnl.page_size", request.PageSize),
+	))
+	defer func() { endSpan(span, response.Error) }()
+
+	// Every log of the request, including the datasource's, carries the request's context.
+	logger := a.Logger.With(
+		slog.String("request_id", logging.NewRequestID()),
+		slog.String("entity", request.Entity.ExternalId),
+		slog.String("cursor", request.Cursor),
+		slog.Int64("page_size", request.PageSize),
+	)
+	ctx = logging.WithLogger(ctx, logger)
+
+	logger.DebugContext(ctx, "Received GetPage request")
+
+	defer func() { logResponse(ctx, logger, response) }()
+
+	if a.DefaultConfig != nil {
+		request.Config = request.Config.WithDefaults(a.DefaultConfig)
+	}
+
+	_, validationSpan := tracer.Start(ctx, "Adapter.ValidateGetPageRequest")
+	err := a.ValidateGetPageRequest(ctx, request)
+	endSpan(validationSpan, err)
+
+	if err != nil {
 		return framework.NewGetPageResponseError(err)
 	}
 
 	return a.RequestPageFromDatasource(ctx, request)
 }
 
+// DateTimeFormats are the formats of the datetime values returned by the datasource, used to parse
+// datetime attributes.
+//
+// SCAFFOLDING #24 - pkg/adapter/adapter.go: List datetime formats supported by your SoR.
+// Provide a list of datetime formats supported by your datasource if
+// they are known. This will optimize the parsing of datetime values.
+// If this is not known, you can omit this option which will try
+// a list of common datetime formats.
+var DateTimeFormats = []web.DateTimeFormatWithTimeZone{
+	{Format: time.RFC3339, HasTimeZone: true},
+	{Format: time.RFC3339Nano, HasTimeZone: true},
+	{Format: "2006-01-02T15:04:05.000Z0700", HasTimeZone: true},
+	{Format: "2006-01-02", HasTimeZone: false},
+}
+
 // RequestPageFromDatasource requests a page of objects from a datasource.
 func (a *Adapter) RequestPageFromDatasource(
 	ctx context.Context, request *framework.Request[Config],
@@ -65,6 +184,12 @@ func (a *Adapter) RequestPageFromDatasource(
 	if !strings.HasPrefix(request.Address, "https://") {
 		request.Address = "https://" + request.Address
 	}
+
+	incremental, cursorErr := incrementalCursor(request, a.Watermarks)
+	if cursorErr != nil {
+		return framework.NewGetPageResponseError(cursorErr)
+	}
+
 	req := &Request{
 		BaseURL: request.Address,
 
@@ -75,7 +200,31 @@ func (a *Adapter) RequestPageFromDatasource(
 		Cursor:           request.Cursor,
 	}
 
+	// References read by JSONPath attributes, e.g. the contact methods of users, are expanded.
+	if jsonPathAttributeNamesEnabled(request.Config) {
+		req.Includes = jsonPathIncludes(ValidEntityExternalIDs[request.Entity.ExternalId], request.Entity.Attributes)
+	}
+
+	if request.Entity.ExternalId == LogEntries && request.Config != nil && request.Config.LogEntries != nil {
+		req.IsOverview = request.Config.LogEntries.IsOverview
+		req.Since = request.Config.LogEntries.Since
+		req.Until = request.Config.LogEntries.Until
+	}
+
+	// The window of an incremental sync takes precedence over any configured time window.
+	if incremental != nil {
+		req.Cursor = incremental.Cursor
+		req.Since = incremental.Since
+		req.Until = incremental.Until
+	}
+
 	resp, err := a.Client.GetPage(ctx, req)
+
+	// Authentication and authorization failures require the datasource configuration to be fixed.
+	if adapterErr := authError(req, resp); adapterErr != nil {
+		return framework.NewGetPageResponseError(adapterErr)
+	}
+
 	if err != nil {
 		return framework.NewGetPageResponseError(err)
 	}
@@ -86,52 +235,113 @@ func (a *Adapter) RequestPageFromDatasource(
 		return framework.NewGetPageResponseError(adapterErr)
 	}
 
-	// The raw JSON objects from the response must be parsed and converted into framework.Objects.
-	// Nested attributes are flattened and delimited by the delimiter specified.
-	// DateTime values are parsed using the specified DateTimeFormatWithTimeZone.
-	parsedObjects, parserErr := web.ConvertJSONObjectList(
-		&request.Entity,
-		resp.Objects,
-
-		// SCAFFOLDING #23 - pkg/adapter/adapter.go: Disable JSONPathAttributeNames.
-		// Disable JSONPathAttributeNames if your datasource does not support
-		// JSONPath attribute names. This should be enabled for most datasources.
-		// PagerDuty does not support JSONPath attribute names. Uncomment the line below if future support is added.
-		//web.WithJSONPathAttributeNames(),
-
-		// SCAFFOLDING #24 - pkg/adapter/adapter.go: List datetime formats supported by your SoR.
-		// Provide a list of datetime formats supported by your datasource if
-		// they are known. This will optimize the parsing of datetime values.
-		// If this is not known, you can omit this option which will try
-		// a list of common datetime formats.
-		web.WithDateTimeFormats(
-			[]web.DateTimeFormatWithTimeZone{
-				{Format: time.RFC3339, HasTimeZone: true},
-				{Format: time.RFC3339Nano, HasTimeZone: true},
-				{Format: "2006-01-02T15:04:05.000Z0700", HasTimeZone: true},
-				{Format: "2006-01-02", HasTimeZone: false},
-			}...,
-		),
+	uniqueIDAttribute := ValidEntityExternalIDs[request.Entity.ExternalId].uniqueIDAttrExternalID
+
+	// Objects without a natural unique ID are given a synthesized one before conversion.
+	if attributes := syntheticIDAttributes(request.Config, request.Entity.ExternalId); len(attributes) > 0 {
+		injectSyntheticIDs(resp.Objects, uniqueIDAttribute, attributes)
+	}
+
+	// PagerDuty can't sort objects by unique ID, so they are sorted within the page, once their
+	// unique IDs are set, if the config accepts it, see Config.OrderWithinPages.
+	if request.Ordered {
+		sortByUniqueID(resp.Objects, uniqueIDAttribute)
+	}
+
+	// Fields that no requested attribute is read from are dropped before conversion, once they are
+	// no longer needed to synthesize unique IDs. Responses are already unmarshaled, so this only
+	// saves the conversion of the dropped fields.
+	fields := projectedFields(uniqueIDAttribute, request.Entity.Attributes, jsonPathAttributeNamesEnabled(request.Config))
+	if fields != nil {
+		project(resp.Objects, fields)
+	}
+
+	// SCAFFOLDING #23 - pkg/adapter/adapter.go: Disable JSONPathAttributeNames.
+	// JSONPath attribute names (e.g. `$.teams[*].id`) are only enabled if requested in the config,
+	// since attribute names starting with `$` were previously matched as plain attribute names.
+	jsonOptions := []web.JSONOption{
+		web.WithDateTimeFormats(DateTimeFormats...),
 
 		// SCAFFOLDING #25 - pkg/adapter/adapter.go: Uncomment to set the default timezone in case the SoR datetime attribute does not have timezone specified.
 		// This can be provided to be used as a default value when parsing
 		// datetime values lacking timezone info. This defaults to UTC.
 		// web.WithLocalTimeZoneOffset(-7),
+	}
+
+	if jsonPathAttributeNamesEnabled(request.Config) {
+		jsonOptions = append(jsonOptions, web.WithJSONPathAttributeNames())
+	}
+
+	// The raw JSON objects from the response must be parsed and converted into framework.Objects.
+	// Nested attributes are flattened and delimited by the delimiter specified.
+	// DateTime values are parsed using the specified DateTimeFormatWithTimeZone.
+	_, conversionSpan := tracer.Start(ctx, "web.ConvertJSONObjectList",
+		trace.WithAttributes(attribute.Int("sgnl.objects", len(resp.Objects))),
 	)
+
+	parsedObjects, parserErr := web.ConvertJSONObjectList(&request.Entity, resp.Objects, jsonOptions...)
 	if parserErr != nil {
-		return framework.NewGetPageResponseError(
-			&framework.Error{
-				Message: fmt.Sprintf("Failed to convert datasource response objects: %v.", parserErr),
-				Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INTERNAL,
-			},
-		)
+		conversionErr := &framework.Error{
+			Message: fmt.Sprintf("Failed to convert datasource response objects: %v.", parserErr),
+			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INTERNAL,
+		}
+		endSpan(conversionSpan, conversionErr)
+
+		return framework.NewGetPageResponseError(conversionErr)
 	}
 
+	endSpan(conversionSpan, nil)
+
 	page := &framework.Page{
 		Objects: parsedObjects,
 	}
 
 	page.NextCursor = resp.NextCursor
 
+	// Incremental syncs keep their time window in the cursor returned to SGNL.
+	// The last cursor must be empty for the sync to complete, and SGNL doesn't pass it to the next
+	// sync anyway, so the new high-water mark is stored for the next sync to start from it.
+	if incremental != nil {
+		incremental.Cursor = resp.NextCursor
+		page.NextCursor = incremental.Encode()
+
+		if page.NextCursor == "" {
+			if a.Watermarks != nil {
+				// The window was validated when the sync started or its cursor was decoded.
+				until, _ := time.Parse(time.RFC3339, incremental.Until)
+				a.Watermarks.Set(watermarkKey(request), until)
+			}
+
+			logging.FromContext(ctx, a.Logger).InfoContext(ctx, "Completed incremental sync",
+				slog.String("since", incremental.Since),
+				slog.String("until", incremental.Until),
+			)
+		}
+	}
+
 	return framework.NewGetPageResponseSuccess(page)
 }
+
+// jsonPathAttributeNamesEnabled returns true if attribute names are parsed as JSONPath expressions.
+func jsonPathAttributeNamesEnabled(config *Config) bool {
+	return config != nil && config.EnableJSONPathAttributeNames
+}
+
+// logResponse logs the outcome of a GetPage request.
+func logResponse(ctx context.Context, logger *slog.Logger, response framework.Response) {
+	if response.Error != nil {
+		logger.WarnContext(ctx, "GetPage request failed",
+			slog.String("error_code", response.Error.Code.String()),
+			slog.String("error", response.Error.Message),
+		)
+
+		return
+	}
+
+	if response.Success != nil {
+		logger.InfoContext(ctx, "Returned page",
+			slog.Int("objects", len(response.Success.Objects)),
+			slog.String("next_cursor", response.Success.NextCursor),
+		)
+	}
+}
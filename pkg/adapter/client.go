@@ -16,6 +16,7 @@ package adapter
 
 import (
 	"context"
+	"log/slog"
 
 	framework "github.com/sgnl-ai/adapter-framework"
 )
@@ -64,6 +65,35 @@ type Request struct {
 
 	// Query is the query to filter the objects.
 	Query string
+
+	// Since is the start of the time window to query, as an RFC3339 timestamp.
+	// Optional. Only set for entities that can be filtered by time.
+	Since string
+
+	// Until is the end of the time window to query, as an RFC3339 timestamp.
+	// Optional. Only set for entities that can be filtered by time.
+	Until string
+
+	// IsOverview restricts log entries to their overview entries.
+	// Optional. Only used by the log_entries entity.
+	IsOverview bool
+
+	// Includes are the values of the include[] query parameter, which expand references to full
+	// objects.
+	// Optional.
+	Includes []string
+}
+
+// LogValue implements slog.LogValuer to log the request without its credentials.
+func (r *Request) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("base_url", r.BaseURL),
+		slog.String("entity", r.EntityExternalID),
+		slog.Int64("page_size", r.PageSize),
+		slog.String("cursor", r.Cursor),
+		slog.String("since", r.Since),
+		slog.String("until", r.Until),
+	)
 }
 
 // SCAFFOLDING #6 - pkg/adapter/client.go: Add/Remove/Update any fields to model the response from the SoR API.
@@ -83,4 +113,7 @@ type Response struct {
 	// page.
 	// May be empty.
 	NextCursor string
+	// APIError is the error returned by the datasource in the body of an unsuccessful response.
+	// May be nil.
+	APIError *APIError
 }
@@ -17,6 +17,8 @@ package adapter
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
 )
 
 // Config is the optional configuration passed in each GetPage calls to the
@@ -27,15 +29,102 @@ type Config struct {
 
 	// Example config field.
 	APIVersion string `json:"apiVersion,omitempty"`
+
+	// SyntheticIDAttributes overrides, per entity external ID, the attributes hashed to synthesize
+	// the unique ID of objects that lack a natural unique ID. Nested attributes are addressed with
+	// "." delimited paths, e.g. "user.id".
+	// Only entities whose unique ID is synthesized can be overridden, e.g. oncalls, not users.
+	// Optional. Defaults to the attributes defined for each entity in ValidEntityExternalIDs.
+	SyntheticIDAttributes map[string][]string `json:"syntheticIdAttributes,omitempty"`
+
+	// Since is the watermark of the first incremental sync, as an RFC3339 timestamp, or as a
+	// duration before the start of the sync, e.g. "24h". Next syncs start from the high-water mark
+	// of the previous one, see Adapter.Watermarks.
+	// If set, entities that support it only return the records created after it: log_entries by
+	// created_at and audit_records by execution_time, which are never updated. Requests for
+	// incidents, which PagerDuty only filters by creation time while they change afterwards, are
+	// rejected. Other entities are fully synced.
+	// Optional. If not set, every entity is fully synced.
+	Since string `json:"since,omitempty"`
+
+	// EnableJSONPathAttributeNames enables attribute names that are JSONPath expressions, e.g.
+	// `$.teams[*].id` or `$.contact_methods[?(@.type=="email_contact_method")].address`, to map
+	// nested and array attributes. String literals in filters must be double-quoted.
+	// The unique ID attribute can then also be requested as `$.id`.
+	// Optional. Disabled by default for backward compatibility.
+	EnableJSONPathAttributeNames bool `json:"enableJSONPathAttributeNames,omitempty"`
+
+	// LogEntries is the configuration of the log_entries entity.
+	// Optional.
+	LogEntries *LogEntriesConfig `json:"logEntries,omitempty"`
+
+	// ClampPageSize reduces the page size of requests that exceed the maximum page size of their
+	// entity to that maximum, instead of rejecting them.
+	// Optional. Disabled by default.
+	ClampPageSize bool `json:"clampPageSize,omitempty"`
+
+	// OrderWithinPages accepts Ordered requests, although PagerDuty can't sort objects by unique ID.
+	// Objects are then sorted by the adapter within each page only, so a page may have objects
+	// ordered before those of the previous page, while SGNL expects Ordered requests to return
+	// objects ordered across pages.
+	// Optional. Disabled by default, Ordered requests are rejected.
+	OrderWithinPages bool `json:"orderWithinPages,omitempty"`
+}
+
+// LogEntriesConfig is the configuration of the log_entries entity.
+type LogEntriesConfig struct {
+	// IsOverview restricts log entries to the overview entries of incidents (triggers,
+	// acknowledgements, resolutions, ...) instead of every notification and escalation.
+	// Optional. By default, all log entries are returned.
+	IsOverview bool `json:"isOverview,omitempty"`
+
+	// Since is the start of the time window of the log entries to return, as an RFC3339 timestamp.
+	// Ignored if the entity is synced incrementally.
+	// Optional. Defaults to PagerDuty's default time window.
+	Since string `json:"since,omitempty"`
+
+	// Until is the end of the time window of the log entries to return, as an RFC3339 timestamp.
+	// Ignored if the entity is synced incrementally.
+	// Optional. Defaults to now.
+	Until string `json:"until,omitempty"`
 }
 
 // ValidateConfig validates that a Config received in a GetPage call is valid.
 func (c *Config) Validate(_ context.Context) error {
 	// SCAFFOLDING #4 - pkg/adapter/config.go: Validate fields passed in Adapter config.
 	// Update the checks below to validate the fields in Config.
-	switch {
-	case c == nil:
+	if c == nil {
 		return errors.New("request contains no config")
+	}
+
+	for entityExternalID, attributes := range c.SyntheticIDAttributes {
+		entity, found := ValidEntityExternalIDs[entityExternalID]
+		if !found {
+			return fmt.Errorf("syntheticIdAttributes contains unknown entity %q", entityExternalID)
+		}
+
+		if len(entity.syntheticIDAttributes) == 0 {
+			return fmt.Errorf("syntheticIdAttributes contains entity %q, whose objects have a natural unique ID", entityExternalID)
+		}
+
+		if len(attributes) == 0 {
+			return fmt.Errorf("syntheticIdAttributes for entity %q must not be empty", entityExternalID)
+		}
+	}
+
+	if c.Since != "" {
+		if _, err := parseSince(c.Since, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	if c.LogEntries != nil {
+		if err := c.LogEntries.validate(); err != nil {
+			return fmt.Errorf("logEntries is invalid: %v", err)
+		}
+	}
+
+	switch {
 	case c.APIVersion == "":
 		//If the APIVersion is not set, set it to "v1"
 		c.APIVersion = "v1"
@@ -44,3 +133,76 @@ func (c *Config) Validate(_ context.Context) error {
 		return nil
 	}
 }
+
+// WithDefaults returns a copy of the config whose unset fields are set from defaults.
+// Synthetic ID attributes are merged per entity, the config's taking precedence.
+// A nil config is replaced by a copy of defaults.
+func (c *Config) WithDefaults(defaults *Config) *Config {
+	var merged Config
+
+	if c != nil {
+		merged = *c
+	}
+
+	if defaults == nil {
+		return &merged
+	}
+
+	if merged.APIVersion == "" {
+		merged.APIVersion = defaults.APIVersion
+	}
+
+	if len(defaults.SyntheticIDAttributes) > 0 {
+		attributes := make(map[string][]string, len(defaults.SyntheticIDAttributes)+len(merged.SyntheticIDAttributes))
+
+		for entityExternalID, attrs := range defaults.SyntheticIDAttributes {
+			attributes[entityExternalID] = attrs
+		}
+
+		for entityExternalID, attrs := range merged.SyntheticIDAttributes {
+			attributes[entityExternalID] = attrs
+		}
+
+		merged.SyntheticIDAttributes = attributes
+	}
+
+	if merged.Since == "" {
+		merged.Since = defaults.Since
+	}
+
+	// A false value cannot be told apart from an unset one, so defaults can only enable it.
+	merged.EnableJSONPathAttributeNames = merged.EnableJSONPathAttributeNames || defaults.EnableJSONPathAttributeNames
+	merged.ClampPageSize = merged.ClampPageSize || defaults.ClampPageSize
+	merged.OrderWithinPages = merged.OrderWithinPages || defaults.OrderWithinPages
+
+	if merged.LogEntries == nil && defaults.LogEntries != nil {
+		logEntries := *defaults.LogEntries
+		merged.LogEntries = &logEntries
+	}
+
+	return &merged
+}
+
+func (c *LogEntriesConfig) validate() error {
+	var since, until time.Time
+
+	var err error
+
+	if c.Since != "" {
+		if since, err = time.Parse(time.RFC3339, c.Since); err != nil {
+			return fmt.Errorf("since must be an RFC3339 timestamp: %v", err)
+		}
+	}
+
+	if c.Until != "" {
+		if until, err = time.Parse(time.RFC3339, c.Until); err != nil {
+			return fmt.Errorf("until must be an RFC3339 timestamp: %v", err)
+		}
+	}
+
+	if c.Since != "" && c.Until != "" && !since.Before(until) {
+		return errors.New("since must be before until")
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tksarunachalam/sgnl-adapter/pkg/adapter"
+)
+
+func TestConfigValidate(t *testing.T) {
+	tests := map[string]struct {
+		config  *adapter.Config
+		wantErr string
+	}{
+		"empty": {
+			config: &adapter.Config{},
+		},
+		"nil": {
+			wantErr: "request contains no config",
+		},
+		"synthetic_id_attributes_override": {
+			config: &adapter.Config{
+				SyntheticIDAttributes: map[string][]string{
+					adapter.OnCalls:             {"escalation_policy.id", "user.id", "start"},
+					adapter.ServiceDependencies: {"supporting_service_id", "dependent_service_id"},
+				},
+			},
+		},
+		"synthetic_id_attributes_unknown_entity": {
+			config: &adapter.Config{
+				SyntheticIDAttributes: map[string][]string{"unknown": {"id"}},
+			},
+			wantErr: `syntheticIdAttributes contains unknown entity "unknown"`,
+		},
+		"synthetic_id_attributes_empty": {
+			config: &adapter.Config{
+				SyntheticIDAttributes: map[string][]string{adapter.OnCalls: {}},
+			},
+			wantErr: `syntheticIdAttributes for entity "oncalls" must not be empty`,
+		},
+		"synthetic_id_attributes_natural_id": {
+			config: &adapter.Config{
+				SyntheticIDAttributes: map[string][]string{adapter.Users: {"email"}},
+			},
+			wantErr: `syntheticIdAttributes contains entity "users", whose objects have a natural unique ID`,
+		},
+		"since_timestamp": {
+			config: &adapter.Config{Since: "2023-01-01T00:00:00Z"},
+		},
+		"since_duration": {
+			config: &adapter.Config{Since: "24h"},
+		},
+		"since_negative_duration": {
+			config:  &adapter.Config{Since: "-24h"},
+			wantErr: "since must be an RFC3339 timestamp or a positive duration",
+		},
+		"since_invalid": {
+			config:  &adapter.Config{Since: "yesterday"},
+			wantErr: "since must be an RFC3339 timestamp",
+		},
+		"log_entries_window_inverted": {
+			config: &adapter.Config{
+				LogEntries: &adapter.LogEntriesConfig{Since: "2023-02-01T00:00:00Z", Until: "2023-01-01T00:00:00Z"},
+			},
+			wantErr: "logEntries is invalid: since must be before until",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := tt.config.Validate(context.Background())
+
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("got error %q, want none", err)
+			case tt.wantErr != "" && err == nil:
+				t.Fatalf("got no error, want %q", tt.wantErr)
+			case tt.wantErr != "" && !strings.Contains(err.Error(), tt.wantErr):
+				t.Fatalf("got error %q, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -27,13 +28,25 @@ import (
 
 	framework "github.com/sgnl-ai/adapter-framework"
 	api_adapter_v1 "github.com/sgnl-ai/adapter-framework/api/adapter/v1"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/logging"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/metrics"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
 )
 
 const (
 	// SCAFFOLDING #11 - pkg/adapter/datasource.go: Update the set of valid entity types this adapter supports.
-	Users   string = "users"
-	Vendors string = "vendors"
-	Teams   string = "teams"
+	Users               string = "users"
+	Vendors             string = "vendors"
+	Teams               string = "teams"
+	BusinessServices    string = "business_services"
+	ServiceDependencies string = "service_dependencies"
+	OnCalls             string = "oncalls"
+	Incidents           string = "incidents"
+	LogEntries          string = "log_entries"
+	AuditRecords        string = "audit_records"
 )
 
 // Entity contains entity specific information, such as the entity's unique ID attribute and the
@@ -46,12 +59,75 @@ type Entity struct {
 	// uniqueIDAttrExternalID is the external ID of the entity's uniqueId attribute.
 	uniqueIDAttrExternalID string
 	endPoint               string
+
+	// responseKey is the field of the SoR response that contains the list of objects.
+	responseKey string
+
+	// syntheticIDAttributes are the attributes hashed to synthesize the unique ID attribute of
+	// objects that don't have a natural unique ID. See SynthesizeID.
+	// Can be overridden per entity in Config.SyntheticIDAttributes.
+	syntheticIDAttributes []string
+
+	// supportsSince is true if the endpoint can filter records by the since and until query
+	// parameters, which allows the entity to be synced incrementally.
+	supportsSince bool
+
+	// changesAfterCreation is true if records of the entity change after they are created, while
+	// its endpoint only filters them by creation time. Incremental syncs, which would miss these
+	// changes, are rejected.
+	changesAfterCreation bool
+
+	// requiredScope is the PagerDuty OAuth scope required to read the entity.
+	requiredScope string
+
+	// cursorPagination is true if the endpoint is paginated with an opaque cursor (cursor and
+	// next_cursor) instead of offsets (offset, limit and more).
+	cursorPagination bool
+
+	// maxPageSize is the maximum page size of requests for the entity.
+	// Defaults to MaxPageSize.
+	maxPageSize int64
+
+	// includes are the values of the include[] query parameter that expand references to full
+	// objects, by the top-level field of the references, e.g. the contact methods of users, which
+	// are otherwise only references without their address. They are sent if a JSONPath attribute
+	// reads the field, see jsonPathIncludes.
+	includes map[string]string
+}
+
+// UniqueIDAttrExternalID returns the external ID of the entity's unique ID attribute.
+func (e Entity) UniqueIDAttrExternalID() string {
+	return e.uniqueIDAttrExternalID
+}
+
+// MaxPageSize returns the maximum page size of requests for the entity.
+func (e Entity) MaxPageSize() int64 {
+	if e.maxPageSize > 0 {
+		return e.maxPageSize
+	}
+
+	return MaxPageSize
 }
 
 // Datasource directly implements a Client interface to allow querying
 // an external datasource.
 type Datasource struct {
 	Client *http.Client
+
+	// RequestTimeout is the timeout of each HTTP request to the datasource.
+	// Defaults to DefaultRequestTimeout.
+	RequestTimeout time.Duration
+
+	// RetryPolicy is the policy to retry failed HTTP requests to the datasource.
+	// By default, requests are not retried.
+	RetryPolicy RetryPolicy
+
+	// RateLimiter limits the rate of HTTP requests to the datasource, if set.
+	RateLimiter *rate.Limiter
+
+	// Logger logs the requests to the datasource, unless the request's context carries a
+	// logger, see logging.WithLogger.
+	Logger *slog.Logger
 }
 
 type DatasourceResponse struct {
@@ -62,6 +138,9 @@ type DatasourceResponse struct {
 	Limit   int              `json:"limit"`
 	Offset  int              `json:"offset"`
 	More    bool             `json:"more"`
+
+	// NextCursor is only returned by endpoints with cursor-based pagination.
+	NextCursor string `json:"next_cursor"`
 }
 
 var (
@@ -73,29 +152,112 @@ var (
 		Users: {
 			uniqueIDAttrExternalID: "id",
 			endPoint:               Users,
+			responseKey:            Users,
+			requiredScope:          "users.read",
+			includes:               map[string]string{"contact_methods": "contact_methods"},
 		},
 		Vendors: {
 			uniqueIDAttrExternalID: "id",
 			endPoint:               Vendors,
+			responseKey:            Vendors,
+			requiredScope:          "vendors.read",
 		},
 		Teams: {
 			uniqueIDAttrExternalID: "id",
 			endPoint:               Teams,
+			responseKey:            Teams,
+			requiredScope:          "teams.read",
+		},
+		BusinessServices: {
+			uniqueIDAttrExternalID: "id",
+			endPoint:               BusinessServices,
+			responseKey:            BusinessServices,
+			requiredScope:          "services.read",
+		},
+		// Service dependencies are not listed by a single endpoint. They are queried per
+		// business service and returned as edges, see getServiceDependenciesPage.
+		// Each business service of a page costs a request, so pages are kept smaller.
+		ServiceDependencies: {
+			uniqueIDAttrExternalID: "id",
+			endPoint:               "service_dependencies/business_services",
+			responseKey:            "relationships",
+			requiredScope:          "services.read",
+			syntheticIDAttributes:  []string{"supporting_service_id", "dependent_service_id"},
+			maxPageSize:            25,
+		},
+		// On-call entries have no ID. An entry is identified by who is on call, for which
+		// escalation policy, level and schedule, and when the on-call shift starts.
+		OnCalls: {
+			uniqueIDAttrExternalID: "id",
+			endPoint:               OnCalls,
+			responseKey:            OnCalls,
+			requiredScope:          "oncalls.read",
+			syntheticIDAttributes: []string{
+				"escalation_policy.id", "escalation_level", "schedule.id", "user.id", "start",
+			},
+		},
+		Incidents: {
+			uniqueIDAttrExternalID: "id",
+			endPoint:               Incidents,
+			responseKey:            Incidents,
+			requiredScope:          "incidents.read",
+			changesAfterCreation:   true,
+		},
+		LogEntries: {
+			uniqueIDAttrExternalID: "id",
+			endPoint:               LogEntries,
+			responseKey:            LogEntries,
+			requiredScope:          "incidents.read",
+			supportsSince:          true,
+		},
+		AuditRecords: {
+			uniqueIDAttrExternalID: "id",
+			endPoint:               "audit/records",
+			responseKey:            "records",
+			requiredScope:          "audit_records.read",
+			supportsSince:          true,
+			cursorPagination:       true,
+			maxPageSize:            1000,
 		},
 	}
 )
 
 // NewClient returns a Client to query the datasource.
-func NewClient(timeout int) Client {
-	return &Datasource{
+// If logger is nil, logs are discarded.
+func NewClient(timeout int, logger *slog.Logger, opts ...ClientOption) Client {
+	if logger == nil {
+		logger = logging.Discard()
+	}
+
+	d := &Datasource{
+		Logger: logger,
 		Client: &http.Client{
 			Timeout: time.Duration(timeout) * time.Second,
+			// Every request to the datasource is traced as a child span of the request's context.
+			Transport: otelhttp.NewTransport(http.DefaultTransport),
 		},
 	}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	return d
 }
 
-func (d *Datasource) GetPage(ctx context.Context, request *Request) (*Response, *framework.Error) {
-	var req *http.Request
+func (d *Datasource) GetPage(ctx context.Context, request *Request) (response *Response, err *framework.Error) {
+	defer observeDatasourceGetPage(request.EntityExternalID, time.Now(), &err)
+
+	ctx, span := tracer.Start(ctx, "Datasource.GetPage", trace.WithAttributes(entityAttribute(request.EntityExternalID)))
+	defer func() { endSpan(span, err) }()
+
+	return d.getPage(ctx, request)
+}
+
+func (d *Datasource) getPage(ctx context.Context, request *Request) (*Response, *framework.Error) {
+	if request.EntityExternalID == ServiceDependencies {
+		return d.getServiceDependenciesPage(ctx, request)
+	}
 
 	// SCAFFOLDING #16 - pkg/adapter/datasource.go: Create the SoR API URL
 	// Populate the request with the appropriate path, headers, and query parameters to query the
@@ -111,16 +273,49 @@ func (d *Datasource) GetPage(ctx context.Context, request *Request) (*Response,
 	// Add query parameters to the URL, if any.
 	addQueryParams(baseUrl, request)
 
-	req, err = http.NewRequestWithContext(ctx, http.MethodGet, baseUrl.String(), nil)
+	response, body, frameworkErr := d.doRequest(ctx, request, baseUrl.String())
+	if frameworkErr != nil || body == nil {
+		return response, frameworkErr
+	}
+
+	// SCAFFOLDING #17-1 - pkg/adapter/datasource.go: To add support for multiple entities that require different parsing functions
+	// Add code to call different ParseResponse functions for each entity response.
+	_, parseSpan := tracer.Start(ctx, "ParseResponse", trace.WithAttributes(attribute.Int("sgnl.response_bytes", len(body))))
+	objects, nextCursor, parseErr := ParseResponse(body)
+	endSpan(parseSpan, parseErr)
+
+	if parseErr != nil {
+		return nil, parseErr
+	}
+
+	if request.EntityExternalID == LogEntries {
+		FlattenLogEntries(objects)
+	}
+
+	response.Objects = objects
+	response.NextCursor = nextCursor
+
+	return response, nil
+}
+
+// doRequestOnce sends a GET request to the given datasource URL and returns the response along with
+// the raw response body. The body is nil if the datasource did not return a successful status code.
+func (d *Datasource) doRequestOnce(ctx context.Context, request *Request, requestURL string) (*Response, []byte, *framework.Error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
 	if err != nil {
-		return nil, &framework.Error{
+		return nil, nil, &framework.Error{
 			Message: "Failed to create HTTP request to datasource.",
 			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INTERNAL,
 		}
 	}
 
-	// Timeout API calls that take longer than 5 seconds
-	apiCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	// Timeout API calls that take longer than the request timeout.
+	requestTimeout := d.RequestTimeout
+	if requestTimeout <= 0 {
+		requestTimeout = DefaultRequestTimeout
+	}
+
+	apiCtx, cancel := context.WithTimeout(ctx, requestTimeout)
 	defer cancel()
 
 	req = req.WithContext(apiCtx)
@@ -138,43 +333,69 @@ func (d *Datasource) GetPage(ctx context.Context, request *Request) (*Response,
 		req.Header.Add("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(auth)))
 	}
 
+	logger := logging.FromContext(ctx, d.Logger)
+
+	// The query parameters of the URL contain no secret.
+	logger.DebugContext(ctx, "Sending request to datasource",
+		slog.String("url", requestURL),
+		slog.Any("headers", req.Header),
+	)
+
+	start := time.Now()
+
 	res, err := d.Client.Do(req)
 	if err != nil {
-		return nil, &framework.Error{
-			Message: "Failed to send request to datasource.",
-			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INTERNAL,
-		}
+		logger.ErrorContext(ctx, "Failed to send request to datasource", slog.String("url", requestURL), slog.Any("error", err))
+
+		return nil, nil, transportError(err)
 	}
 
+	defer res.Body.Close()
+
+	metrics.DatasourceHTTPDuration.
+		WithLabelValues(entityLabel(request.EntityExternalID), strconv.Itoa(res.StatusCode)).
+		Observe(time.Since(start).Seconds())
+
+	logger.DebugContext(ctx, "Received response from datasource",
+		slog.String("url", requestURL),
+		slog.Int("status_code", res.StatusCode),
+		slog.Duration("duration", time.Since(start)),
+	)
+
 	response := &Response{
 		StatusCode:       res.StatusCode,
 		RetryAfterHeader: res.Header.Get("Retry-After"),
 	}
 
-	if res.StatusCode != http.StatusOK {
-		return response, nil
-	}
-
-	defer res.Body.Close()
-
 	body, err := io.ReadAll(res.Body)
 	if err != nil {
-		return nil, &framework.Error{
+		return nil, nil, &framework.Error{
 			Message: "Failed to read response body.",
 			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_FAILED,
 		}
 	}
-	// SCAFFOLDING #17-1 - pkg/adapter/datasource.go: To add support for multiple entities that require different parsing functions
-	// Add code to call different ParseResponse functions for each entity response.
-	objects, nextCursor, parseErr := ParseResponse(body)
-	if parseErr != nil {
-		return nil, parseErr
+
+	if res.StatusCode != http.StatusOK {
+		response.APIError = ParseAPIError(body)
+
+		adapterErr := statusError(response)
+		if adapterErr == nil {
+			// Other successful status codes, e.g. 204 No Content, have no page to parse.
+			return response, nil, nil
+		}
+
+		logger.WarnContext(ctx, "Datasource returned an unsuccessful status code",
+			slog.String("url", requestURL),
+			slog.Int("status_code", res.StatusCode),
+			slog.String("error", adapterErr.Message),
+		)
+
+		return response, nil, adapterErr
 	}
 
-	response.Objects = objects
-	response.NextCursor = nextCursor
+	metrics.DatasourceResponseBytes.WithLabelValues(entityLabel(request.EntityExternalID)).Observe(float64(len(body)))
 
-	return response, nil
+	return response, body, nil
 }
 
 func ParseResponse(body []byte) (objects []map[string]any, nextCursor string, err *framework.Error) {
@@ -196,7 +417,10 @@ func ParseResponse(body []byte) (objects []map[string]any, nextCursor string, er
 	// SCAFFOLDING #19 - pkg/adapter/datasource.go: Populate next page information (called cursor in SGNL adapters).
 	// Populate nextCursor with the cursor returned from the datasource, if present.
 	nextCursor = ""
-	if data.More {
+	if data.NextCursor != "" {
+		// Endpoints with cursor-based pagination return the next cursor as is.
+		nextCursor = data.NextCursor
+	} else if data.More {
 		// If there are more pages, next cursor is the offset + limit.
 		nextCursor = strconv.Itoa(data.Offset + data.Limit)
 	}
@@ -219,8 +443,8 @@ func (d *DatasourceResponse) UnmarshalJSON(data []byte) error {
 	// Supports unmarshal of different entities in the response.
 	// Add more entities as needed in ValidEntityExternalIDs map.
 	found := false
-	for key := range ValidEntityExternalIDs {
-		if value, exists := raw[key]; exists {
+	for _, entity := range ValidEntityExternalIDs {
+		if value, exists := raw[entity.responseKey]; exists {
 			var objects []map[string]any
 			if err := json.Unmarshal(value, &objects); err == nil {
 				d.Objects = objects
@@ -246,6 +470,16 @@ func (d *DatasourceResponse) UnmarshalJSON(data []byte) error {
 			return err
 		}
 	}
+	if value, exists := raw["next_cursor"]; exists {
+		// next_cursor is null on the last page.
+		var nextCursor *string
+		if err := json.Unmarshal(value, &nextCursor); err != nil {
+			return err
+		}
+		if nextCursor != nil {
+			d.NextCursor = *nextCursor
+		}
+	}
 
 	if !found {
 		return fmt.Errorf("no valid objects found in JSON")
@@ -260,7 +494,23 @@ func addQueryParams(baseUrl *url.URL, request *Request) {
 		query.Add("limit", fmt.Sprintf("%d", request.PageSize))
 	}
 	if request.Cursor != "" {
-		query.Add("offset", request.Cursor)
+		if ValidEntityExternalIDs[request.EntityExternalID].cursorPagination {
+			query.Add("cursor", request.Cursor)
+		} else {
+			query.Add("offset", request.Cursor)
+		}
+	}
+	if request.Since != "" {
+		query.Add("since", request.Since)
+	}
+	if request.Until != "" {
+		query.Add("until", request.Until)
+	}
+	if request.IsOverview {
+		query.Add("is_overview", "true")
+	}
+	for _, include := range request.Includes {
+		query.Add("include[]", include)
 	}
 	if request.Total {
 		query.Add("total", "true")
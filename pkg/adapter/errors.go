@@ -0,0 +1,240 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+	api_adapter_v1 "github.com/sgnl-ai/adapter-framework/api/adapter/v1"
+	"github.com/sgnl-ai/adapter-framework/web"
+)
+
+// APIError is the error returned by PagerDuty in the body of unsuccessful responses.
+type APIError struct {
+	// Code is PagerDuty's error code.
+	Code int `json:"code"`
+
+	// Message is the error message.
+	Message string `json:"message"`
+
+	// Errors are the detailed error messages, if any.
+	Errors []string `json:"errors"`
+}
+
+// String returns the error message, code and details.
+func (e *APIError) String() string {
+	var b strings.Builder
+
+	b.WriteString(e.Message)
+
+	if e.Code != 0 {
+		fmt.Fprintf(&b, " (code %d)", e.Code)
+	}
+
+	if len(e.Errors) > 0 {
+		fmt.Fprintf(&b, ": %s", strings.Join(e.Errors, "; "))
+	}
+
+	return b.String()
+}
+
+// ParseAPIError parses the error returned by PagerDuty in the body of an unsuccessful response.
+// Returns nil if the body does not contain a PagerDuty error.
+func ParseAPIError(body []byte) *APIError {
+	var data struct {
+		Error *APIError `json:"error"`
+	}
+
+	if err := json.Unmarshal(body, &data); err != nil || data.Error == nil {
+		return nil
+	}
+
+	if data.Error.Message == "" && data.Error.Code == 0 && len(data.Error.Errors) == 0 {
+		return nil
+	}
+
+	return data.Error
+}
+
+// transportError returns the error returned to SGNL when an HTTP request to the datasource could
+// not be completed, depending on the cause of the failure.
+func transportError(err error) *framework.Error {
+	var (
+		dnsErr         *net.DNSError
+		unknownCAErr   x509.UnknownAuthorityError
+		hostnameErr    x509.HostnameError
+		certInvalidErr x509.CertificateInvalidError
+		certVerifyErr  *tls.CertificateVerificationError
+		recordErr      tls.RecordHeaderError
+		netErr         net.Error
+	)
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return &framework.Error{
+			Message: "Request to datasource was cancelled.",
+			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_TEMPORARILY_UNAVAILABLE,
+		}
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return &framework.Error{
+			Message: "Request to datasource timed out.",
+			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_TEMPORARILY_UNAVAILABLE,
+		}
+	case errors.As(err, &dnsErr):
+		if dnsErr.IsNotFound {
+			return &framework.Error{
+				Message: fmt.Sprintf("Failed to resolve datasource host %s. Check the datasource address.", dnsErr.Name),
+				Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_DATASOURCE_CONFIG,
+			}
+		}
+
+		return &framework.Error{
+			Message: fmt.Sprintf("Failed to resolve datasource host %s: %v.", dnsErr.Name, dnsErr.Err),
+			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_TEMPORARILY_UNAVAILABLE,
+		}
+	case errors.As(err, &unknownCAErr), errors.As(err, &hostnameErr), errors.As(err, &certInvalidErr),
+		errors.As(err, &certVerifyErr), errors.As(err, &recordErr):
+		return &framework.Error{
+			Message: fmt.Sprintf("Failed to establish a TLS connection to datasource: %v.", unwrapURLError(err)),
+			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_FAILED,
+		}
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return &framework.Error{
+			Message: "Datasource refused the connection. Check the datasource address.",
+			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_TEMPORARILY_UNAVAILABLE,
+		}
+	default:
+		return &framework.Error{
+			Message: fmt.Sprintf("Failed to send request to datasource: %v.", unwrapURLError(err)),
+			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_FAILED,
+		}
+	}
+}
+
+// unwrapURLError returns the cause of a *url.Error returned by http.Client, whose message
+// otherwise repeats the method and URL of the request.
+func unwrapURLError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+
+	return err
+}
+
+// statusError returns the error returned to SGNL when the datasource returned an unsuccessful
+// status code, including the details of the PagerDuty error returned in the response, if any.
+func statusError(response *Response) *framework.Error {
+	adapterErr := web.HTTPError(response.StatusCode, response.RetryAfterHeader)
+	if adapterErr == nil {
+		return nil
+	}
+
+	switch {
+	case response.StatusCode == http.StatusNotFound:
+		adapterErr.Message = "Datasource returned 404 Not Found. Check the datasource address and entity."
+		adapterErr.Code = api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_DATASOURCE_CONFIG
+	case response.StatusCode >= 500 && !strings.Contains(adapterErr.Message, strconv.Itoa(response.StatusCode)):
+		// Include the status code in every 5xx message.
+		adapterErr.Message = fmt.Sprintf("%s Returned status code: %d.", adapterErr.Message, response.StatusCode)
+	}
+
+	if response.APIError != nil {
+		adapterErr.Message = fmt.Sprintf("%s PagerDuty error: %s.", adapterErr.Message, response.APIError)
+	}
+
+	return adapterErr
+}
+
+// authError returns the error returned to SGNL when PagerDuty rejected the credentials of the
+// request (401) or the credentials lack a permission (403), or nil for any other response.
+// These are returned as ERROR_CODE_INVALID_DATASOURCE_CONFIG with a message explaining what to fix,
+// since retrying the request cannot succeed until the datasource configuration is updated.
+func authError(request *Request, response *Response) *framework.Error {
+	if response == nil {
+		return nil
+	}
+
+	var message string
+
+	switch response.StatusCode {
+	case http.StatusUnauthorized:
+		message = "PagerDuty rejected the API token: it is invalid, expired or revoked."
+
+		if !strings.HasPrefix(request.Token, "Token token=") && !strings.HasPrefix(request.Token, "Bearer ") {
+			message += " The datasource authorization must be formatted as \"Token token=<API key>\" for REST API keys " +
+				"or \"Bearer <token>\" for OAuth tokens."
+		} else {
+			message += " Generate a new API key or OAuth token and update the datasource authorization."
+		}
+	case http.StatusForbidden:
+		scope := ValidEntityExternalIDs[request.EntityExternalID].requiredScope
+		if missing := missingScope(response.APIError); missing != "" {
+			scope = missing
+		}
+
+		message = fmt.Sprintf("PagerDuty denied access to the %s entity.", request.EntityExternalID)
+
+		if scope != "" {
+			message += fmt.Sprintf(" Grant the %q scope to the OAuth token, or use a REST API key of a user with "+
+				"access to this resource.", scope)
+		} else {
+			message += " Use an API key of a user with access to this resource."
+		}
+	default:
+		return nil
+	}
+
+	if response.APIError != nil {
+		message += fmt.Sprintf(" PagerDuty error: %s.", response.APIError)
+	}
+
+	return &framework.Error{
+		Message: message,
+		Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_DATASOURCE_CONFIG,
+	}
+}
+
+// scopePattern matches the name of a PagerDuty OAuth scope, e.g. "users.read".
+var scopePattern = regexp.MustCompile(`\b[a-z_]+\.(read|write)\b`)
+
+// missingScope returns the OAuth scope named in a PagerDuty error, or an empty string if the error
+// names no scope.
+func missingScope(apiErr *APIError) string {
+	if apiErr == nil {
+		return ""
+	}
+
+	for _, message := range append([]string{apiErr.Message}, apiErr.Errors...) {
+		if scope := scopePattern.FindString(message); scope != "" {
+			return scope
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,349 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+	api_adapter_v1 "github.com/sgnl-ai/adapter-framework/api/adapter/v1"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/logging"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/pagerdutymock"
+)
+
+func TestTransportError(t *testing.T) {
+	// server is a datasource that responds after a second, with a certificate that isn't trusted.
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	// Handshakes rejected by clients are expected.
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	// Connections to the address of a closed listener are refused.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	refusedURL := "http://" + listener.Addr().String()
+	listener.Close()
+
+	// get returns the error of a GET request to the URL with the client.
+	get := func(ctx context.Context, client *http.Client, target string) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		res, err := client.Do(req)
+		if err == nil {
+			res.Body.Close()
+			t.Fatalf("Got a response from %s, want an error", target)
+		}
+
+		return err
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := map[string]struct {
+		err         func() error
+		wantCode    api_adapter_v1.ErrorCode
+		wantMessage string
+	}{
+		"connection_refused": {
+			err: func() error {
+				return get(context.Background(), http.DefaultClient, refusedURL)
+			},
+			wantCode:    api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_TEMPORARILY_UNAVAILABLE,
+			wantMessage: "Datasource refused the connection.",
+		},
+		"host_not_found": {
+			err: func() error {
+				return &url.Error{Op: "Get", URL: "https://acme.pagerduty.invalid", Err: &net.OpError{
+					Op:  "dial",
+					Net: "tcp",
+					Err: &net.DNSError{Err: "no such host", Name: "acme.pagerduty.invalid", IsNotFound: true},
+				}}
+			},
+			wantCode:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_DATASOURCE_CONFIG,
+			wantMessage: "Failed to resolve datasource host acme.pagerduty.invalid.",
+		},
+		"dns_server_failure": {
+			err: func() error {
+				return &url.Error{Op: "Get", URL: "https://api.pagerduty.com", Err: &net.OpError{
+					Op:  "dial",
+					Net: "tcp",
+					Err: &net.DNSError{Err: "server misbehaving", Name: "api.pagerduty.com", IsTemporary: true},
+				}}
+			},
+			wantCode:    api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_TEMPORARILY_UNAVAILABLE,
+			wantMessage: "Failed to resolve datasource host api.pagerduty.com: server misbehaving.",
+		},
+		"client_timeout": {
+			err: func() error {
+				return get(context.Background(), &http.Client{Transport: server.Client().Transport, Timeout: 50 * time.Millisecond}, server.URL)
+			},
+			wantCode:    api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_TEMPORARILY_UNAVAILABLE,
+			wantMessage: "Request to datasource timed out.",
+		},
+		"context_deadline": {
+			err: func() error {
+				ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+				defer cancel()
+
+				return get(ctx, server.Client(), server.URL)
+			},
+			wantCode:    api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_TEMPORARILY_UNAVAILABLE,
+			wantMessage: "Request to datasource timed out.",
+		},
+		"context_cancelled": {
+			err: func() error {
+				return get(cancelled, server.Client(), server.URL)
+			},
+			wantCode:    api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_TEMPORARILY_UNAVAILABLE,
+			wantMessage: "Request to datasource was cancelled.",
+		},
+		"tls_unknown_ca": {
+			err: func() error {
+				return get(context.Background(), &http.Client{}, server.URL)
+			},
+			wantCode:    api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_FAILED,
+			wantMessage: "Failed to establish a TLS connection to datasource: tls: failed to verify certificate: x509:",
+		},
+		"other": {
+			err: func() error {
+				return &url.Error{Op: "Get", URL: "https://api.pagerduty.com", Err: errors.New("unexpected EOF")}
+			},
+			wantCode:    api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_FAILED,
+			wantMessage: "Failed to send request to datasource: unexpected EOF.",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := transportError(tt.err())
+
+			if got.Code != tt.wantCode || !strings.HasPrefix(got.Message, tt.wantMessage) {
+				t.Errorf("Got error %s: %q, want %s: %q", got.Code, got.Message, tt.wantCode, tt.wantMessage)
+			}
+		})
+	}
+}
+
+// TestStatusErrors verifies the errors returned to SGNL for the unsuccessful responses injected in
+// the fake API of package pagerdutymock.
+func TestStatusErrors(t *testing.T) {
+	mock := pagerdutymock.NewTestServer(t, nil)
+
+	a := &Adapter{
+		Client: NewClient(10, nil, WithTransport(mock.Client.Transport)),
+		Logger: logging.Discard(),
+	}
+
+	tests := map[string]struct {
+		entity string
+		token  string
+		fault  pagerdutymock.Fault
+
+		wantCode       api_adapter_v1.ErrorCode
+		wantMessages   []string
+		wantRetryAfter time.Duration
+	}{
+		"unauthorized": {
+			entity:   Users,
+			fault:    pagerdutymock.Fault{Status: http.StatusUnauthorized},
+			wantCode: api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_DATASOURCE_CONFIG,
+			wantMessages: []string{
+				"PagerDuty rejected the API token: it is invalid, expired or revoked.",
+				"Generate a new API key or OAuth token",
+				"PagerDuty error: Unauthorized (code 2006).",
+			},
+		},
+		"unauthorized_malformed_token": {
+			entity:       Users,
+			token:        "u+abcdef",
+			fault:        pagerdutymock.Fault{Status: http.StatusUnauthorized},
+			wantCode:     api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_DATASOURCE_CONFIG,
+			wantMessages: []string{`must be formatted as "Token token=<API key>"`},
+		},
+		"forbidden": {
+			entity:   Teams,
+			fault:    pagerdutymock.Fault{Status: http.StatusForbidden},
+			wantCode: api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_DATASOURCE_CONFIG,
+			wantMessages: []string{
+				"PagerDuty denied access to the teams entity.",
+				`Grant the "teams.read" scope to the OAuth token`,
+			},
+		},
+		// The scope named by PagerDuty takes precedence over the one of the entity.
+		"forbidden_missing_scope": {
+			entity:       LogEntries,
+			fault:        pagerdutymock.Fault{Status: http.StatusForbidden},
+			wantCode:     api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_DATASOURCE_CONFIG,
+			wantMessages: []string{`Grant the "incidents.read" scope`, "required scope incidents.read"},
+		},
+		"not_found": {
+			entity:       Users,
+			fault:        pagerdutymock.Fault{Status: http.StatusNotFound},
+			wantCode:     api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_DATASOURCE_CONFIG,
+			wantMessages: []string{"Datasource returned 404 Not Found.", "PagerDuty error: Not Found (code 2100)."},
+		},
+		"rate_limited": {
+			entity:         Users,
+			fault:          pagerdutymock.Fault{Status: http.StatusTooManyRequests, RetryAfter: 30},
+			wantCode:       api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_TOO_MANY_REQUESTS,
+			wantMessages:   []string{"PagerDuty error: Rate Limit Exceeded (code 2020)."},
+			wantRetryAfter: 30 * time.Second,
+		},
+		"internal_server_error": {
+			entity:       Users,
+			fault:        pagerdutymock.Fault{Status: http.StatusInternalServerError},
+			wantCode:     api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_FAILED,
+			wantMessages: []string{"Returned status code: 500.", "PagerDuty error: Internal Server Error (code 2000)."},
+		},
+		"service_unavailable": {
+			entity:       Users,
+			fault:        pagerdutymock.Fault{Status: http.StatusServiceUnavailable},
+			wantCode:     api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_TEMPORARILY_UNAVAILABLE,
+			wantMessages: []string{"returned status code: 503."},
+		},
+		"http_version_not_supported": {
+			entity:       Users,
+			fault:        pagerdutymock.Fault{Status: http.StatusHTTPVersionNotSupported},
+			wantCode:     api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_PERMANENTLY_UNAVAILABLE,
+			wantMessages: []string{"Returned status code: 505."},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			mock.ClearFaults()
+			mock.AddFault(tt.fault)
+
+			if tt.token == "" {
+				tt.token = "Token token=test"
+			}
+
+			response := a.GetPage(context.Background(), &framework.Request[Config]{
+				Address: mock.URL,
+				Auth:    &framework.DatasourceAuthCredentials{HTTPAuthorization: tt.token},
+				Config:  &Config{},
+				Entity: framework.EntityConfig{
+					ExternalId: tt.entity,
+					Attributes: []*framework.AttributeConfig{{ExternalId: "id", Type: framework.AttributeTypeString}},
+				},
+				PageSize: 10,
+			})
+
+			if response.Error == nil {
+				t.Fatal("got no error")
+			}
+
+			if response.Error.Code != tt.wantCode {
+				t.Errorf("got error %s: %s, want code %s", response.Error.Code, response.Error.Message, tt.wantCode)
+			}
+
+			for _, want := range tt.wantMessages {
+				if !strings.Contains(response.Error.Message, want) {
+					t.Errorf("got message %q, want it to contain %q", response.Error.Message, want)
+				}
+			}
+
+			if tt.wantRetryAfter != 0 && (response.Error.RetryAfter == nil || *response.Error.RetryAfter != tt.wantRetryAfter) {
+				t.Errorf("got retry after %v, want %s", response.Error.RetryAfter, tt.wantRetryAfter)
+			}
+		})
+	}
+}
+
+func TestClientTimeout(t *testing.T) {
+	mock := pagerdutymock.NewTestServer(t, nil)
+	mock.AddFault(pagerdutymock.Fault{Delay: time.Minute})
+
+	a := &Adapter{
+		Client: NewClient(0, nil, WithTransport(mock.Client.Transport), WithClientTimeout(100*time.Millisecond)),
+		Logger: logging.Discard(),
+	}
+
+	start := time.Now()
+
+	response := a.GetPage(context.Background(), &framework.Request[Config]{
+		Address: mock.URL,
+		Auth:    &framework.DatasourceAuthCredentials{HTTPAuthorization: "Token token=test"},
+		Config:  &Config{},
+		Entity: framework.EntityConfig{
+			ExternalId: Users,
+			Attributes: []*framework.AttributeConfig{{ExternalId: "id", Type: framework.AttributeTypeString}},
+		},
+		PageSize: 10,
+	})
+
+	if response.Error == nil || response.Error.Message != "Request to datasource timed out." {
+		t.Fatalf("got error %v, want a timeout", response.Error)
+	}
+
+	// The request timeout is DefaultRequestTimeout, so the sub-second client timeout expired first.
+	if elapsed := time.Since(start); elapsed >= DefaultRequestTimeout {
+		t.Errorf("got timeout after %s, want it after the client timeout", elapsed)
+	}
+}
+
+func TestMissingScope(t *testing.T) {
+	tests := map[string]struct {
+		apiErr *APIError
+		want   string
+	}{
+		"no_error": {},
+		"in_message": {
+			apiErr: &APIError{Message: "Token is missing the users.read scope"},
+			want:   "users.read",
+		},
+		"in_errors": {
+			apiErr: &APIError{
+				Message: "Access Denied",
+				Errors:  []string{"Access token does not have the required scope audit_records.read"},
+			},
+			want: "audit_records.read",
+		},
+		"no_scope": {
+			apiErr: &APIError{Message: "Access Denied", Errors: []string{"User is not an account owner"}},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := missingScope(tt.apiErr); got != tt.want {
+				t.Errorf("got scope %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
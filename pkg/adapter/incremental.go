@@ -0,0 +1,141 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+	api_adapter_v1 "github.com/sgnl-ai/adapter-framework/api/adapter/v1"
+)
+
+// IncrementalCursor is the cursor returned to SGNL for entities synced incrementally.
+// It carries the time window of the sync alongside the datasource cursor, so every page of a
+// sync queries the same window even if records are updated while the sync is in progress.
+type IncrementalCursor struct {
+	// Cursor is the datasource cursor that identifies the first object of the next page.
+	Cursor string `json:"cursor,omitempty"`
+
+	// Since is the watermark of the sync, as an RFC3339 timestamp: only records created after it
+	// are returned. It is the high-water mark of the previous sync if one is stored, else the
+	// watermark from the Config. A watermark configured as a duration is resolved when the sync
+	// starts.
+	Since string `json:"since"`
+
+	// Until is the new high-water mark, i.e. the time at which the sync started. It is stored when
+	// the sync completes, as the Since watermark of the next delta sync.
+	Until string `json:"until"`
+}
+
+// incrementalCursor returns the cursor of an incremental sync for the request, or nil if the
+// requested entity must be fully synced, i.e. if no watermark is configured or the entity does
+// not support filtering by time.
+// The first page of a sync starts a new window ending now, from the high-water mark of the
+// previous sync if the watermarks store one, next pages reuse the window encoded in their cursor.
+// If watermarks is nil, every sync starts from the configured watermark.
+func incrementalCursor(request *framework.Request[Config], watermarks Watermarks) (*IncrementalCursor, *framework.Error) {
+	if request.Config == nil || request.Config.Since == "" || !ValidEntityExternalIDs[request.Entity.ExternalId].supportsSince {
+		return nil, nil
+	}
+
+	if request.Cursor == "" {
+		now := time.Now().UTC()
+
+		since, err := parseSince(request.Config.Since, now)
+		if err != nil {
+			return nil, &framework.Error{
+				Message: fmt.Sprintf("Provided config is invalid: %v.", err),
+				Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_DATASOURCE_CONFIG,
+			}
+		}
+
+		if watermarks != nil {
+			if mark, found := watermarks.Get(watermarkKey(request)); found {
+				since = mark
+			}
+		}
+
+		return &IncrementalCursor{
+			Since: since.Format(time.RFC3339),
+			Until: now.Format(time.RFC3339),
+		}, nil
+	}
+
+	cursor, err := DecodeIncrementalCursor(request.Cursor)
+	if err != nil {
+		return nil, &framework.Error{
+			Message: fmt.Sprintf("Provided cursor is invalid: %v.", err),
+			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_PAGE_REQUEST_CONFIG,
+		}
+	}
+
+	return cursor, nil
+}
+
+// parseSince returns the watermark of the since config field, an RFC3339 timestamp or a positive
+// duration before now.
+func parseSince(since string, now time.Time) (time.Time, error) {
+	if watermark, err := time.Parse(time.RFC3339, since); err == nil {
+		return watermark, nil
+	}
+
+	lookback, err := time.ParseDuration(since)
+	if err != nil || lookback <= 0 {
+		return time.Time{}, fmt.Errorf("since must be an RFC3339 timestamp or a positive duration, e.g. 24h: %q", since)
+	}
+
+	return now.Add(-lookback), nil
+}
+
+// Encode returns the cursor as an opaque string, or an empty string if the cursor has no next page.
+func (c *IncrementalCursor) Encode() string {
+	if c.Cursor == "" {
+		return ""
+	}
+
+	encoded, err := json.Marshal(c)
+	if err != nil {
+		// A struct of strings can always be marshaled.
+		panic(err)
+	}
+
+	return base64.URLEncoding.EncodeToString(encoded)
+}
+
+// DecodeIncrementalCursor decodes a cursor previously returned by IncrementalCursor.Encode.
+func DecodeIncrementalCursor(encoded string) (*IncrementalCursor, error) {
+	decoded, err := base64.URLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	var cursor IncrementalCursor
+	if err := json.Unmarshal(decoded, &cursor); err != nil {
+		return nil, err
+	}
+
+	if _, err := time.Parse(time.RFC3339, cursor.Since); err != nil {
+		return nil, fmt.Errorf("since is not a valid RFC3339 timestamp: %w", err)
+	}
+
+	if _, err := time.Parse(time.RFC3339, cursor.Until); err != nil {
+		return nil, fmt.Errorf("until is not a valid RFC3339 timestamp: %w", err)
+	}
+
+	return &cursor, nil
+}
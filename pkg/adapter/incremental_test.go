@@ -0,0 +1,302 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+	api_adapter_v1 "github.com/sgnl-ai/adapter-framework/api/adapter/v1"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/pagerdutymock"
+)
+
+func TestDecodeIncrementalCursor(t *testing.T) {
+	cursor := &IncrementalCursor{
+		Cursor: "25",
+		Since:  "2023-01-01T00:00:00Z",
+		Until:  "2023-02-01T00:00:00Z",
+	}
+
+	decoded, err := DecodeIncrementalCursor(cursor.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *decoded != *cursor {
+		t.Errorf("got cursor %+v, want %+v", *decoded, *cursor)
+	}
+
+	encode := func(s string) string {
+		return base64.URLEncoding.EncodeToString([]byte(s))
+	}
+
+	invalid := map[string]string{
+		"not_base64":    "not base64!",
+		"not_json":      encode("25"),
+		"invalid_since": encode(`{"cursor": "25", "since": "yesterday", "until": "2023-02-01T00:00:00Z"}`),
+		"invalid_until": encode(`{"cursor": "25", "since": "2023-01-01T00:00:00Z", "until": ""}`),
+	}
+
+	for name, encoded := range invalid {
+		t.Run(name, func(t *testing.T) {
+			if _, err := DecodeIncrementalCursor(encoded); err == nil {
+				t.Errorf("decoded invalid cursor %q", encoded)
+			}
+		})
+	}
+}
+
+func TestIncrementalCursorEncode(t *testing.T) {
+	cursor := &IncrementalCursor{Since: "2023-01-01T00:00:00Z", Until: "2023-02-01T00:00:00Z"}
+
+	if encoded := cursor.Encode(); encoded != "" {
+		t.Errorf("got cursor %q without a next page, want none", encoded)
+	}
+}
+
+func TestIncrementalCursor(t *testing.T) {
+	next := (&IncrementalCursor{
+		Cursor: "25",
+		Since:  "2023-01-01T00:00:00Z",
+		Until:  "2023-02-01T00:00:00Z",
+	}).Encode()
+
+	tests := map[string]struct {
+		entity  string
+		config  *Config
+		cursor  string
+		want    *IncrementalCursor
+		wantErr api_adapter_v1.ErrorCode
+
+		// wantLookback is the expected duration between the start and the end of a new window.
+		wantLookback time.Duration
+	}{
+		"no_config": {
+			entity: LogEntries,
+		},
+		"no_since": {
+			entity: LogEntries,
+			config: &Config{},
+		},
+		"unsupported_entity": {
+			entity: Users,
+			config: &Config{Since: "2023-01-01T00:00:00Z"},
+		},
+		"changes_after_creation": {
+			entity: Incidents,
+			config: &Config{Since: "2023-01-01T00:00:00Z"},
+		},
+		"first_page_timestamp": {
+			entity: LogEntries,
+			config: &Config{Since: "2023-01-01T00:00:00Z"},
+			want:   &IncrementalCursor{Since: "2023-01-01T00:00:00Z"},
+		},
+		"first_page_duration": {
+			entity:       AuditRecords,
+			config:       &Config{Since: "24h"},
+			wantLookback: 24 * time.Hour,
+		},
+		"next_page": {
+			entity: LogEntries,
+			config: &Config{Since: "24h"},
+			cursor: next,
+			want: &IncrementalCursor{
+				Cursor: "25",
+				Since:  "2023-01-01T00:00:00Z",
+				Until:  "2023-02-01T00:00:00Z",
+			},
+		},
+		"invalid_cursor": {
+			entity:  LogEntries,
+			config:  &Config{Since: "2023-01-01T00:00:00Z"},
+			cursor:  "25",
+			wantErr: api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_PAGE_REQUEST_CONFIG,
+		},
+		"invalid_since": {
+			entity:  LogEntries,
+			config:  &Config{Since: "-24h"},
+			wantErr: api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_DATASOURCE_CONFIG,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			start := time.Now().UTC().Truncate(time.Second)
+
+			got, err := incrementalCursor(&framework.Request[Config]{
+				Config: tt.config,
+				Entity: framework.EntityConfig{ExternalId: tt.entity},
+				Cursor: tt.cursor,
+			}, nil)
+
+			if tt.wantErr != 0 {
+				if err == nil || err.Code != tt.wantErr {
+					t.Fatalf("got error %v, want code %s", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("got error %s: %s", err.Code, err.Message)
+			}
+
+			if tt.want == nil && tt.wantLookback == 0 {
+				if got != nil {
+					t.Fatalf("got cursor %+v for a full sync, want nil", *got)
+				}
+
+				return
+			}
+
+			if got == nil {
+				t.Fatal("got no cursor")
+			}
+
+			// A new window ends when the sync starts.
+			if tt.cursor == "" {
+				until, parseErr := time.Parse(time.RFC3339, got.Until)
+				if parseErr != nil || until.Before(start) || until.After(time.Now()) {
+					t.Errorf("got until %q, want the start of the sync %s", got.Until, start)
+				}
+
+				if tt.wantLookback > 0 {
+					since, parseErr := time.Parse(time.RFC3339, got.Since)
+					if parseErr != nil || until.Sub(since) != tt.wantLookback {
+						t.Errorf("got since %q, want %s before until %q", got.Since, tt.wantLookback, got.Until)
+					}
+
+					return
+				}
+
+				tt.want.Until = got.Until
+			}
+
+			if *got != *tt.want {
+				t.Errorf("got cursor %+v, want %+v", *got, *tt.want)
+			}
+		})
+	}
+}
+
+// TestIncrementalSyncLastPage verifies that the window of an incremental sync is logged with its
+// last page, including when the sync has a single page.
+func TestIncrementalSyncLastPage(t *testing.T) {
+	mock := pagerdutymock.NewTestServer(t, nil)
+
+	var logs bytes.Buffer
+
+	a := &Adapter{
+		Client: NewClient(10, nil, WithTransport(mock.Client.Transport)),
+		Logger: slog.New(slog.NewTextHandler(&logs, nil)),
+	}
+
+	response := a.GetPage(context.Background(), &framework.Request[Config]{
+		Address: strings.TrimPrefix(mock.URL, "https://"),
+		Auth:    &framework.DatasourceAuthCredentials{HTTPAuthorization: "Token token=test"},
+		Config:  &Config{Since: pagerdutymock.FixturesEpoch.Format(time.RFC3339)},
+		Entity: framework.EntityConfig{
+			ExternalId: AuditRecords,
+			Attributes: []*framework.AttributeConfig{{ExternalId: "id", Type: framework.AttributeTypeString}},
+		},
+		PageSize: MaxPageSize,
+	})
+
+	if response.Error != nil {
+		t.Fatalf("got error %s: %s", response.Error.Code, response.Error.Message)
+	}
+
+	if response.Success.NextCursor != "" {
+		t.Errorf("got cursor %q for the last page, want none", response.Success.NextCursor)
+	}
+
+	if len(response.Success.Objects) == 0 {
+		t.Error("got no audit records")
+	}
+
+	if !strings.Contains(logs.String(), `msg="Completed incremental sync"`) || !strings.Contains(logs.String(), "since=2023-01-01T00:00:00Z until=") {
+		t.Errorf("the window of the sync is not logged:\n%s", logs.String())
+	}
+}
+
+// TestIncrementalSyncWatermark verifies that an incremental sync starts from the high-water mark of
+// the previous sync of the same entity and credentials.
+func TestIncrementalSyncWatermark(t *testing.T) {
+	mock := pagerdutymock.NewTestServer(t, nil)
+
+	a := NewAdapter(NewClient(10, nil, WithTransport(mock.Client.Transport)), nil)
+
+	// sync runs a sync of log entries to completion, and returns the since and until of its window,
+	// as requested from PagerDuty, and the number of synced objects.
+	sync := func(token string) (since, until string, objects int) {
+		t.Helper()
+
+		first := len(mock.Requests())
+
+		request := &framework.Request[Config]{
+			Address: strings.TrimPrefix(mock.URL, "https://"),
+			Auth:    &framework.DatasourceAuthCredentials{HTTPAuthorization: token},
+			Config:  &Config{Since: pagerdutymock.FixturesEpoch.Format(time.RFC3339)},
+			Entity: framework.EntityConfig{
+				ExternalId: LogEntries,
+				Attributes: []*framework.AttributeConfig{{ExternalId: "id", Type: framework.AttributeTypeString}},
+			},
+			PageSize: 50,
+		}
+
+		for page := 0; ; page++ {
+			if page == 100 {
+				t.Fatal("got no last page after 100 pages")
+			}
+
+			response := a.GetPage(context.Background(), request)
+			if response.Error != nil {
+				t.Fatalf("got error %s: %s", response.Error.Code, response.Error.Message)
+			}
+
+			objects += len(response.Success.Objects)
+
+			if response.Success.NextCursor == "" {
+				break
+			}
+
+			request.Cursor = response.Success.NextCursor
+		}
+
+		query := mock.Requests()[first].URL.Query()
+
+		return query.Get("since"), query.Get("until"), objects
+	}
+
+	since, until, objects := sync("Token token=test")
+	if since != "2023-01-01T00:00:00Z" || objects == 0 {
+		t.Fatalf("got %d log entries since %q on the first sync, want the fixtures since the configured watermark", objects, since)
+	}
+
+	// The fixtures were all created before the first sync, so the second one has none to return.
+	if since, _, objects := sync("Token token=test"); since != until || objects != 0 {
+		t.Errorf("got %d log entries since %q on the second sync, want none since %q", objects, since, until)
+	}
+
+	if since, _, _ := sync("Token token=other"); since != "2023-01-01T00:00:00Z" {
+		t.Errorf("got since %q with other credentials, want the configured watermark", since)
+	}
+}
@@ -0,0 +1,313 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter_test
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+	api_adapter_v1 "github.com/sgnl-ai/adapter-framework/api/adapter/v1"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/adapter"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/adaptertest"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/logging"
+)
+
+// TestJSONPathAttributes verifies that nested and array attributes of every entity are resolved
+// from the objects returned by PagerDuty.
+func TestJSONPathAttributes(t *testing.T) {
+	mock, client := adaptertest.NewMockClient(t, nil)
+	fixtures := mock.Fixtures
+
+	a := &adapter.Adapter{Client: client, Logger: logging.Discard()}
+
+	tests := []struct {
+		entity     string
+		attributes []*framework.AttributeConfig
+
+		// synthesized is true if the unique ID of the entity's objects is synthesized, and is then
+		// only verified to be set.
+		synthesized bool
+
+		// want returns the expected object of a fixture of the entity's collection.
+		want func(fixture map[string]any) map[string]any
+	}{
+		{
+			entity: adapter.Users,
+			attributes: []*framework.AttributeConfig{
+				stringAttribute("$.id"),
+				stringAttribute("$.name"),
+				listAttribute("$.teams[*].id"),
+				listAttribute(`$.contact_methods[?(@.type=="email_contact_method")].address`),
+			},
+			want: func(user map[string]any) map[string]any {
+				return map[string]any{
+					"$.id":          user["id"],
+					"$.name":        user["name"],
+					"$.teams[*].id": ids(user["teams"]),
+					// The contact methods are only references, unless expanded with include[].
+					`$.contact_methods[?(@.type=="email_contact_method")].address`: []any{user["email"]},
+				}
+			},
+		},
+		{
+			entity: adapter.Teams,
+			attributes: []*framework.AttributeConfig{
+				stringAttribute("$.id"),
+				stringAttribute("$.name"),
+			},
+			want: func(team map[string]any) map[string]any {
+				return map[string]any{"$.id": team["id"], "$.name": team["name"]}
+			},
+		},
+		{
+			entity: adapter.Vendors,
+			attributes: []*framework.AttributeConfig{
+				stringAttribute("$.id"),
+				stringAttribute(`$["website_url"]`),
+			},
+			want: func(vendor map[string]any) map[string]any {
+				return map[string]any{"$.id": vendor["id"], `$["website_url"]`: vendor["website_url"]}
+			},
+		},
+		{
+			entity: adapter.BusinessServices,
+			attributes: []*framework.AttributeConfig{
+				stringAttribute("$.id"),
+				stringAttribute("$.team.id"),
+			},
+			want: func(businessService map[string]any) map[string]any {
+				return map[string]any{"$.id": businessService["id"], "$.team.id": field(businessService, "team", "id")}
+			},
+		},
+		{
+			entity:      adapter.ServiceDependencies,
+			synthesized: true,
+			attributes: []*framework.AttributeConfig{
+				stringAttribute("$.id"),
+				stringAttribute("$.supporting_service_id"),
+				stringAttribute("$.dependent_service_type"),
+			},
+			want: func(relationship map[string]any) map[string]any {
+				return map[string]any{
+					"$.supporting_service_id":  field(relationship, "supporting_service", "id"),
+					"$.dependent_service_type": field(relationship, "dependent_service", "type"),
+				}
+			},
+		},
+		{
+			entity:      adapter.OnCalls,
+			synthesized: true,
+			attributes: []*framework.AttributeConfig{
+				stringAttribute("$.id"),
+				stringAttribute("$.user.id"),
+				stringAttribute("$.schedule.id"),
+				stringAttribute("$.escalation_policy.summary"),
+			},
+			want: func(onCall map[string]any) map[string]any {
+				return map[string]any{
+					"$.user.id":                   field(onCall, "user", "id"),
+					"$.schedule.id":               field(onCall, "schedule", "id"),
+					"$.escalation_policy.summary": field(onCall, "escalation_policy", "summary"),
+				}
+			},
+		},
+		{
+			entity: adapter.Incidents,
+			attributes: []*framework.AttributeConfig{
+				stringAttribute("$.id"),
+				stringAttribute("$.service.id"),
+				listAttribute("$.teams[*].id"),
+				listAttribute("$.assignments[*].assignee.id"),
+			},
+			want: func(incident map[string]any) map[string]any {
+				// Resolved incidents have no assignments.
+				assignees := []any{}
+				for _, assignment := range incident["assignments"].([]any) {
+					assignees = append(assignees, field(assignment.(map[string]any), "assignee", "id"))
+				}
+
+				return map[string]any{
+					"$.id":                         incident["id"],
+					"$.service.id":                 field(incident, "service", "id"),
+					"$.teams[*].id":                ids(incident["teams"]),
+					"$.assignments[*].assignee.id": assignees,
+				}
+			},
+		},
+		{
+			entity: adapter.LogEntries,
+			attributes: []*framework.AttributeConfig{
+				stringAttribute("$.id"),
+				stringAttribute("$.incident.id"),
+				stringAttribute("$.agent_id"),
+			},
+			want: func(logEntry map[string]any) map[string]any {
+				return map[string]any{
+					"$.id":          logEntry["id"],
+					"$.incident.id": field(logEntry, "incident", "id"),
+					"$.agent_id":    field(logEntry, "agent", "id"),
+				}
+			},
+		},
+		{
+			entity: adapter.AuditRecords,
+			attributes: []*framework.AttributeConfig{
+				stringAttribute("$.id"),
+				listAttribute("$.actors[*].id"),
+				stringAttribute("$.root_resource.type"),
+				listAttribute(`$.details.fields[?(@.name=="name")].value`),
+			},
+			want: func(record map[string]any) map[string]any {
+				return map[string]any{
+					"$.id":                 record["id"],
+					"$.actors[*].id":       ids(record["actors"]),
+					"$.root_resource.type": field(record, "root_resource", "type"),
+					`$.details.fields[?(@.name=="name")].value`: []any{field(record, "root_resource", "summary")},
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.entity, func(t *testing.T) {
+			var want []string
+			for _, fixture := range fixtures[tt.entity] {
+				want = append(want, encode(t, tt.want(fixture)))
+			}
+
+			var got []string
+
+			cursor := ""
+
+			for {
+				response := a.GetPage(context.Background(), &framework.Request[adapter.Config]{
+					Address: strings.TrimPrefix(mock.URL, "https://"),
+					Auth:    &framework.DatasourceAuthCredentials{HTTPAuthorization: "Token token=test"},
+					Config:  &adapter.Config{EnableJSONPathAttributeNames: true},
+					Entity: framework.EntityConfig{
+						ExternalId: tt.entity,
+						Attributes: tt.attributes,
+					},
+					PageSize: adapter.ValidEntityExternalIDs[tt.entity].MaxPageSize(),
+					Cursor:   cursor,
+				})
+				if response.Error != nil {
+					t.Fatalf("got error %s: %s", response.Error.Code, response.Error.Message)
+				}
+
+				for _, object := range response.Success.Objects {
+					if tt.synthesized {
+						if id, _ := object["$.id"].(string); len(id) != 64 {
+							t.Errorf("got $.id %q, want a synthesized ID", id)
+						}
+
+						delete(object, "$.id")
+					}
+
+					got = append(got, encode(t, object))
+				}
+
+				if cursor = response.Success.NextCursor; cursor == "" {
+					break
+				}
+			}
+
+			sort.Strings(got)
+			sort.Strings(want)
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got objects:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+			}
+		})
+	}
+}
+
+func TestJSONPathAttributesInvalid(t *testing.T) {
+	a := &adapter.Adapter{Logger: logging.Discard()}
+
+	for _, externalID := range []string{
+		`$.contact_methods[?(@.type=='email_contact_method')].address`,
+		"$.teams[",
+		"$.",
+	} {
+		err := a.ValidateGetPageRequest(context.Background(), &framework.Request[adapter.Config]{
+			Auth:   &framework.DatasourceAuthCredentials{HTTPAuthorization: "Token token=test"},
+			Config: &adapter.Config{EnableJSONPathAttributeNames: true},
+			Entity: framework.EntityConfig{
+				ExternalId: adapter.Users,
+				Attributes: []*framework.AttributeConfig{stringAttribute("$.id"), listAttribute(externalID)},
+			},
+			PageSize: 10,
+		})
+
+		if err == nil || err.Code != api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_ENTITY_CONFIG {
+			t.Errorf("attribute %q: got error %v, want an invalid entity config", externalID, err)
+		}
+	}
+}
+
+func stringAttribute(externalID string) *framework.AttributeConfig {
+	return &framework.AttributeConfig{ExternalId: externalID, Type: framework.AttributeTypeString}
+}
+
+func listAttribute(externalID string) *framework.AttributeConfig {
+	return &framework.AttributeConfig{ExternalId: externalID, Type: framework.AttributeTypeString, List: true}
+}
+
+// field returns the value of a nested field of an object, or nil if it doesn't exist.
+func field(object map[string]any, path ...string) any {
+	var value any = object
+
+	for _, key := range path {
+		nested, _ := value.(map[string]any)
+		value = nested[key]
+	}
+
+	return value
+}
+
+// ids returns the IDs of a list of references.
+func ids(references any) []any {
+	var ids []any
+
+	for _, reference := range references.([]any) {
+		ids = append(ids, reference.(map[string]any)["id"])
+	}
+
+	return ids
+}
+
+// encode returns the JSON encoding of an object without its nil attributes, to compare objects.
+func encode(t *testing.T, object map[string]any) string {
+	t.Helper()
+
+	for key, value := range object {
+		if value == nil {
+			delete(object, key)
+		}
+	}
+
+	data, err := json.Marshal(object)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(data)
+}
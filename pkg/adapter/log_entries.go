@@ -0,0 +1,49 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+var (
+	// logEntryAgentAttributes are the attributes of the polymorphic agent object of a log entry
+	// (user_reference, service_reference, integration_reference, ...) kept as agent_<attribute>.
+	logEntryAgentAttributes = []string{"id", "type", "summary", "html_url"}
+
+	// logEntryChannelAttributes are the attributes of the polymorphic channel object of a log entry
+	// (web_trigger, email, api, auto, timeout, ...) kept as channel_<attribute>.
+	logEntryChannelAttributes = []string{
+		"type", "summary", "subject", "description", "client", "client_url", "from", "to",
+	}
+)
+
+// FlattenLogEntries replaces the agent and channel objects of each log entry with flat
+// agent_<attribute> and channel_<attribute> attributes.
+// The shape of these objects depends on the type of log entry, so every flattened attribute is
+// set on every entry (to nil if missing) for all entries to have the same attributes.
+func FlattenLogEntries(objects []map[string]any) {
+	for _, object := range objects {
+		flattenLogEntryObject(object, "agent", logEntryAgentAttributes)
+		flattenLogEntryObject(object, "channel", logEntryChannelAttributes)
+	}
+}
+
+func flattenLogEntryObject(object map[string]any, key string, attributes []string) {
+	nested, _ := object[key].(map[string]any)
+
+	for _, attribute := range attributes {
+		// Indexing a nil map returns nil for entries without this object.
+		object[key+"_"+attribute] = nested[attribute]
+	}
+
+	delete(object, key)
+}
@@ -0,0 +1,124 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tksarunachalam/sgnl-adapter/pkg/adapter"
+)
+
+func TestFlattenLogEntries(t *testing.T) {
+	tests := map[string]struct {
+		object map[string]any
+		want   map[string]any
+	}{
+		"agent_and_channel": {
+			object: map[string]any{
+				"id":   "R1",
+				"type": "trigger_log_entry",
+				"agent": map[string]any{
+					"id":       "PSERVICE",
+					"type":     "service_reference",
+					"summary":  "Checkout",
+					"html_url": "https://acme.pagerduty.com/services/PSERVICE",
+					"self":     "https://api.pagerduty.com/services/PSERVICE",
+				},
+				"channel": map[string]any{
+					"type":    "email",
+					"summary": "Disk full",
+					"subject": "Disk full on db-1",
+					"from":    "alerts@acme.com",
+					"to":      "checkout@acme.pagerduty.com",
+				},
+			},
+			want: map[string]any{
+				"id":                  "R1",
+				"type":                "trigger_log_entry",
+				"agent_id":            "PSERVICE",
+				"agent_type":          "service_reference",
+				"agent_summary":       "Checkout",
+				"agent_html_url":      "https://acme.pagerduty.com/services/PSERVICE",
+				"channel_type":        "email",
+				"channel_summary":     "Disk full",
+				"channel_subject":     "Disk full on db-1",
+				"channel_description": nil,
+				"channel_client":      nil,
+				"channel_client_url":  nil,
+				"channel_from":        "alerts@acme.com",
+				"channel_to":          "checkout@acme.pagerduty.com",
+			},
+		},
+		// Log entries generated by PagerDuty itself, e.g. escalations on timeout, have no agent.
+		"missing_agent": {
+			object: map[string]any{
+				"id":      "R2",
+				"type":    "escalate_log_entry",
+				"channel": map[string]any{"type": "timeout", "summary": "Escalated on timeout"},
+			},
+			want: map[string]any{
+				"id":                  "R2",
+				"type":                "escalate_log_entry",
+				"agent_id":            nil,
+				"agent_type":          nil,
+				"agent_summary":       nil,
+				"agent_html_url":      nil,
+				"channel_type":        "timeout",
+				"channel_summary":     "Escalated on timeout",
+				"channel_subject":     nil,
+				"channel_description": nil,
+				"channel_client":      nil,
+				"channel_client_url":  nil,
+				"channel_from":        nil,
+				"channel_to":          nil,
+			},
+		},
+		"null_agent_and_unexpected_channel": {
+			object: map[string]any{
+				"id":      "R3",
+				"agent":   nil,
+				"channel": "api",
+			},
+			want: map[string]any{
+				"id":                  "R3",
+				"agent_id":            nil,
+				"agent_type":          nil,
+				"agent_summary":       nil,
+				"agent_html_url":      nil,
+				"channel_type":        nil,
+				"channel_summary":     nil,
+				"channel_subject":     nil,
+				"channel_description": nil,
+				"channel_client":      nil,
+				"channel_client_url":  nil,
+				"channel_from":        nil,
+				"channel_to":          nil,
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			objects := []map[string]any{tt.object}
+
+			adapter.FlattenLogEntries(objects)
+
+			if !reflect.DeepEqual(objects[0], tt.want) {
+				t.Errorf("Got log entry %v, want %v", objects[0], tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"time"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/metrics"
+)
+
+// entityLabel returns the value of the entity metric label for the entity external ID.
+// Invalid external IDs are grouped under a single label value to bound the metrics cardinality.
+func entityLabel(entityExternalID string) string {
+	if _, found := ValidEntityExternalIDs[entityExternalID]; !found {
+		return "unknown"
+	}
+
+	return entityExternalID
+}
+
+// errorCodeLabel returns the value of the code metric label for the error.
+func errorCodeLabel(err *framework.Error) string {
+	if err == nil {
+		return metrics.CodeOK
+	}
+
+	return err.Code.String()
+}
+
+// observeGetPage records the metrics of a GetPage request to the adapter that started at start.
+func observeGetPage(entityExternalID string, start time.Time, response *framework.Response) {
+	entity := entityLabel(entityExternalID)
+	code := errorCodeLabel(response.Error)
+
+	metrics.GetPageDuration.WithLabelValues(entity).Observe(time.Since(start).Seconds())
+	metrics.GetPageRequests.WithLabelValues(entity, code).Inc()
+
+	if response.Success != nil {
+		metrics.GetPageObjects.WithLabelValues(entity).Observe(float64(len(response.Success.Objects)))
+	}
+}
+
+// observeDatasourceGetPage records the metrics of a page requested from the datasource that started at start.
+func observeDatasourceGetPage(entityExternalID string, start time.Time, err **framework.Error) {
+	entity := entityLabel(entityExternalID)
+	code := errorCodeLabel(*err)
+
+	metrics.DatasourceDuration.WithLabelValues(entity).Observe(time.Since(start).Seconds())
+	metrics.DatasourceRequests.WithLabelValues(entity, code).Inc()
+}
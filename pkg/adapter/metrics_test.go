@@ -0,0 +1,139 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	framework "github.com/sgnl-ai/adapter-framework"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/adapter"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/adaptertest"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/logging"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/metrics"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/pagerdutymock"
+)
+
+// histogram returns the number and the sum of the observations of a histogram.
+func histogram(t *testing.T, vec *prometheus.HistogramVec, labels ...string) (uint64, float64) {
+	t.Helper()
+
+	var metric dto.Metric
+	if err := vec.WithLabelValues(labels...).(prometheus.Metric).Write(&metric); err != nil {
+		t.Fatal(err)
+	}
+
+	return metric.GetHistogram().GetSampleCount(), metric.GetHistogram().GetSampleSum()
+}
+
+// TestMetrics verifies the metrics recorded for successful and failed requests. Metrics are global,
+// so the test verifies their increase.
+func TestMetrics(t *testing.T) {
+	mock, client := adaptertest.NewMockClient(t, nil)
+
+	a := &adapter.Adapter{Client: client, Logger: logging.Discard()}
+
+	getPage := func(entity string) {
+		a.GetPage(context.Background(), &framework.Request[adapter.Config]{
+			Address:  mock.URL,
+			Auth:     &framework.DatasourceAuthCredentials{HTTPAuthorization: "Token token=test"},
+			Config:   &adapter.Config{},
+			Entity:   framework.EntityConfig{ExternalId: entity, Attributes: []*framework.AttributeConfig{stringAttribute("id")}},
+			PageSize: 10,
+		})
+	}
+
+	const failed = "ERROR_CODE_DATASOURCE_FAILED"
+
+	counters := map[string]prometheus.Counter{
+		"getpage_ok":                 metrics.GetPageRequests.WithLabelValues(adapter.Users, metrics.CodeOK),
+		"getpage_failed":             metrics.GetPageRequests.WithLabelValues(adapter.Users, failed),
+		"getpage_unknown_entity":     metrics.GetPageRequests.WithLabelValues("unknown", "ERROR_CODE_INVALID_ENTITY_CONFIG"),
+		"datasource_ok":              metrics.DatasourceRequests.WithLabelValues(adapter.Users, metrics.CodeOK),
+		"datasource_failed":          metrics.DatasourceRequests.WithLabelValues(adapter.Users, failed),
+		"datasource_unknown_entity":  metrics.DatasourceRequests.WithLabelValues("unknown", metrics.CodeOK),
+		"datasource_unrelated_label": metrics.DatasourceRequests.WithLabelValues(adapter.Teams, metrics.CodeOK),
+	}
+
+	before := make(map[string]float64, len(counters))
+	for name, counter := range counters {
+		before[name] = testutil.ToFloat64(counter)
+	}
+
+	histograms := map[string]struct {
+		vec       *prometheus.HistogramVec
+		labels    []string
+		wantCount uint64
+	}{
+		"getpage_duration":         {metrics.GetPageDuration, []string{adapter.Users}, 2},
+		"getpage_objects":          {metrics.GetPageObjects, []string{adapter.Users}, 1},
+		"datasource_duration":      {metrics.DatasourceDuration, []string{adapter.Users}, 2},
+		"datasource_http_200":      {metrics.DatasourceHTTPDuration, []string{adapter.Users, "200"}, 1},
+		"datasource_http_500":      {metrics.DatasourceHTTPDuration, []string{adapter.Users, "500"}, 1},
+		"datasource_response_size": {metrics.DatasourceResponseBytes, []string{adapter.Users}, 1},
+	}
+
+	beforeCounts := make(map[string]uint64, len(histograms))
+	for name, h := range histograms {
+		beforeCounts[name], _ = histogram(t, h.vec, h.labels...)
+	}
+
+	_, objectsBefore := histogram(t, metrics.GetPageObjects, adapter.Users)
+	_, bytesBefore := histogram(t, metrics.DatasourceResponseBytes, adapter.Users)
+
+	getPage(adapter.Users)
+
+	mock.AddFault(pagerdutymock.Fault{Status: http.StatusInternalServerError, Times: 1})
+	getPage(adapter.Users)
+
+	// Requests for invalid entities are rejected before the datasource is queried.
+	getPage("pets")
+
+	want := map[string]float64{
+		"getpage_ok":             1,
+		"getpage_failed":         1,
+		"getpage_unknown_entity": 1,
+		"datasource_ok":          1,
+		"datasource_failed":      1,
+	}
+
+	for name, counter := range counters {
+		if got := testutil.ToFloat64(counter) - before[name]; got != want[name] {
+			t.Errorf("Got %s increased by %v, want %v", name, got, want[name])
+		}
+	}
+
+	for name, h := range histograms {
+		if count, _ := histogram(t, h.vec, h.labels...); count-beforeCounts[name] != h.wantCount {
+			t.Errorf("Got %d more observations of %s, want %d", count-beforeCounts[name], name, h.wantCount)
+		}
+	}
+
+	_, objectsAfter := histogram(t, metrics.GetPageObjects, adapter.Users)
+	_, bytesAfter := histogram(t, metrics.DatasourceResponseBytes, adapter.Users)
+
+	// Only the successful page is observed, with its 10 objects.
+	if got := objectsAfter - objectsBefore; got != 10 {
+		t.Errorf("Got %v more objects observed, want 10", got)
+	}
+
+	if bytesAfter <= bytesBefore {
+		t.Error("Got no response bytes observed")
+	}
+}
@@ -0,0 +1,39 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"fmt"
+	"sort"
+)
+
+// sortByUniqueID sorts the objects by the string value of their unique ID attribute. Objects
+// without one are sorted first, in their original order.
+func sortByUniqueID(objects []map[string]any, uniqueIDAttribute string) {
+	sort.SliceStable(objects, func(i, j int) bool {
+		return uniqueIDString(objects[i], uniqueIDAttribute) < uniqueIDString(objects[j], uniqueIDAttribute)
+	})
+}
+
+func uniqueIDString(object map[string]any, uniqueIDAttribute string) string {
+	switch id := object[uniqueIDAttribute].(type) {
+	case nil:
+		return ""
+	case string:
+		return id
+	default:
+		return fmt.Sprint(id)
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter_test
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+	api_adapter_v1 "github.com/sgnl-ai/adapter-framework/api/adapter/v1"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/adapter"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/adaptertest"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/logging"
+)
+
+func TestOrderedRequests(t *testing.T) {
+	mock, client := adaptertest.NewMockClient(t, nil)
+
+	a := &adapter.Adapter{Client: client, Logger: logging.Discard()}
+
+	tests := map[string]struct {
+		entity  string
+		config  *adapter.Config
+		wantErr bool
+	}{
+		"users_rejected": {
+			entity:  adapter.Users,
+			config:  &adapter.Config{},
+			wantErr: true,
+		},
+		"oncalls_rejected": {
+			entity:  adapter.OnCalls,
+			config:  &adapter.Config{},
+			wantErr: true,
+		},
+		"users_within_pages": {
+			entity: adapter.Users,
+			config: &adapter.Config{OrderWithinPages: true},
+		},
+		// The synthesized unique IDs are sorted, once set.
+		"oncalls_within_pages": {
+			entity: adapter.OnCalls,
+			config: &adapter.Config{OrderWithinPages: true},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			response := a.GetPage(context.Background(), &framework.Request[adapter.Config]{
+				Address:  mock.URL,
+				Auth:     &framework.DatasourceAuthCredentials{HTTPAuthorization: "Token token=test"},
+				Config:   tt.config,
+				Entity:   framework.EntityConfig{ExternalId: tt.entity, Attributes: []*framework.AttributeConfig{stringAttribute("id")}},
+				Ordered:  true,
+				PageSize: 10,
+			})
+
+			if tt.wantErr {
+				if response.Error == nil || response.Error.Code != api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_ENTITY_CONFIG {
+					t.Fatalf("Got response %+v, want an invalid entity config error", response)
+				}
+
+				return
+			}
+
+			if response.Error != nil {
+				t.Fatalf("Got error %s: %s", response.Error.Code, response.Error.Message)
+			}
+
+			var got []string
+			for _, object := range response.Success.Objects {
+				got = append(got, object["id"].(string))
+			}
+
+			if len(got) == 0 || !sort.StringsAreSorted(got) {
+				t.Errorf("Got unique IDs %v, want them sorted", got)
+			}
+		})
+	}
+}
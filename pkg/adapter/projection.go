@@ -0,0 +1,108 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"sort"
+	"strings"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+)
+
+// projectedFields returns the top-level fields of the objects that the requested attributes are
+// read from, always including the unique ID attribute, or nil if every field must be kept, e.g.
+// for JSONPath expressions with a recursive descent.
+// If jsonPaths is false, attribute names starting with "$" are plain field names.
+func projectedFields(uniqueIDAttribute string, attributes []*framework.AttributeConfig, jsonPaths bool) map[string]struct{} {
+	fields := map[string]struct{}{uniqueIDAttribute: {}}
+
+	for _, attribute := range attributes {
+		field := attribute.ExternalId
+
+		if jsonPaths && strings.HasPrefix(field, "$") {
+			var ok bool
+			if field, ok = jsonPathField(field); !ok {
+				return nil
+			}
+		}
+
+		fields[field] = struct{}{}
+	}
+
+	return fields
+}
+
+// jsonPathField returns the top-level field of a JSONPath expression, e.g. teams for
+// $.teams[*].id or $['teams'][0].id, or false if the expression may read any field.
+func jsonPathField(expression string) (string, bool) {
+	path := strings.TrimPrefix(expression, "$")
+
+	switch {
+	case strings.HasPrefix(path, ".."):
+		return "", false
+	case strings.HasPrefix(path, "."):
+		field := path[1:]
+		if end := strings.IndexAny(field, ".["); end >= 0 {
+			field = field[:end]
+		}
+
+		return field, field != "" && field != "*"
+	case strings.HasPrefix(path, "['"), strings.HasPrefix(path, `["`):
+		quote := path[1]
+
+		end := strings.IndexByte(path[2:], quote)
+		if end < 0 {
+			return "", false
+		}
+
+		return path[2 : 2+end], true
+	default:
+		return "", false
+	}
+}
+
+// jsonPathIncludes returns the include[] values of the entity that expand the references read by
+// JSONPath attributes, sorted, or every include[] value of the entity if an expression may read
+// any field.
+func jsonPathIncludes(entity Entity, attributes []*framework.AttributeConfig) []string {
+	if len(entity.includes) == 0 {
+		return nil
+	}
+
+	fields := projectedFields(entity.uniqueIDAttrExternalID, attributes, true)
+
+	var includes []string
+
+	for field, include := range entity.includes {
+		if _, read := fields[field]; read || fields == nil {
+			includes = append(includes, include)
+		}
+	}
+
+	sort.Strings(includes)
+
+	return includes
+}
+
+// project removes the fields of the objects that are not in fields.
+func project(objects []map[string]any, fields map[string]struct{}) {
+	for _, object := range objects {
+		for field := range object {
+			if _, requested := fields[field]; !requested {
+				delete(object, field)
+			}
+		}
+	}
+}
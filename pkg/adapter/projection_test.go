@@ -0,0 +1,173 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"reflect"
+	"testing"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+)
+
+func TestJSONPathField(t *testing.T) {
+	tests := map[string]struct {
+		expression string
+		want       string
+		wantOK     bool
+	}{
+		"field": {
+			expression: "$.id",
+			want:       "id",
+			wantOK:     true,
+		},
+		"nested_field": {
+			expression: "$.escalation_policy.id",
+			want:       "escalation_policy",
+			wantOK:     true,
+		},
+		"deeply_nested_field": {
+			expression: "$.assignments.assignee.summary",
+			want:       "assignments",
+			wantOK:     true,
+		},
+		"wildcard_index": {
+			expression: "$.teams[*].id",
+			want:       "teams",
+			wantOK:     true,
+		},
+		"index": {
+			expression: "$.teams[0].id",
+			want:       "teams",
+			wantOK:     true,
+		},
+		"filter": {
+			expression: `$.contact_methods[?(@.type=="email_contact_method")].address`,
+			want:       "contact_methods",
+			wantOK:     true,
+		},
+		"single_quoted_bracket": {
+			expression: "$['teams'][0].id",
+			want:       "teams",
+			wantOK:     true,
+		},
+		"double_quoted_bracket": {
+			expression: `$["teams"][*].id`,
+			want:       "teams",
+			wantOK:     true,
+		},
+		"recursive_descent": {
+			expression: "$..id",
+		},
+		"wildcard_field": {
+			expression: "$.*.id",
+		},
+		"unterminated_bracket": {
+			expression: "$['teams",
+		},
+		"root": {
+			expression: "$",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			// The field is only relevant if the expression reads a single field.
+			got, ok := jsonPathField(tt.expression)
+			if ok != tt.wantOK || (ok && got != tt.want) {
+				t.Errorf("got field %q, %t, want %q, %t", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestProjectedFields(t *testing.T) {
+	tests := map[string]struct {
+		attributes []string
+		jsonPaths  bool
+
+		// want are the projected fields, or nil if every field is kept.
+		want []string
+	}{
+		"plain_attributes": {
+			attributes: []string{"id", "name", "email"},
+			want:       []string{"id", "name", "email"},
+		},
+		"unique_id_always_kept": {
+			attributes: []string{"name"},
+			want:       []string{"id", "name"},
+		},
+		"nested_json_paths": {
+			attributes: []string{"$.id", "$.teams[*].id", "$.contact_methods[*].address", "$.role"},
+			jsonPaths:  true,
+			want:       []string{"id", "teams", "contact_methods", "role"},
+		},
+		"json_path_with_unique_id_missing": {
+			attributes: []string{"$.teams[*].id"},
+			jsonPaths:  true,
+			want:       []string{"id", "teams"},
+		},
+		"recursive_descent_keeps_every_field": {
+			attributes: []string{"$.id", "$..id"},
+			jsonPaths:  true,
+		},
+		"json_paths_disabled": {
+			attributes: []string{"id", "$.teams[*].id"},
+			want:       []string{"id", "$.teams[*].id"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			attributes := make([]*framework.AttributeConfig, 0, len(tt.attributes))
+			for _, attribute := range tt.attributes {
+				attributes = append(attributes, &framework.AttributeConfig{ExternalId: attribute})
+			}
+
+			got := projectedFields("id", attributes, tt.jsonPaths)
+
+			var want map[string]struct{}
+			if tt.want != nil {
+				want = make(map[string]struct{}, len(tt.want))
+				for _, field := range tt.want {
+					want[field] = struct{}{}
+				}
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got fields %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestProject(t *testing.T) {
+	objects := []map[string]any{{
+		"id":    "P1",
+		"name":  "Jane",
+		"teams": []any{map[string]any{"id": "T1"}},
+		"role":  "admin",
+	}}
+
+	project(objects, map[string]struct{}{"id": {}, "teams": {}})
+
+	want := []map[string]any{{
+		"id":    "P1",
+		"teams": []any{map[string]any{"id": "T1"}},
+	}}
+
+	if !reflect.DeepEqual(objects, want) {
+		t.Errorf("got objects %v, want %v", objects, want)
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+	api_adapter_v1 "github.com/sgnl-ai/adapter-framework/api/adapter/v1"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/logging"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"golang.org/x/time/rate"
+)
+
+// DefaultRequestTimeout is the default timeout of each HTTP request to the datasource.
+const DefaultRequestTimeout = 5 * time.Second
+
+// RetryPolicy is the policy to retry HTTP requests to the datasource that failed with a transient
+// error: a 429 or 5xx status code, a timeout or a connection failure.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts of a request, including the first one.
+	// Requests are not retried if lower than 2.
+	MaxAttempts int
+
+	// InitialBackoff is the delay before the first retry. The delay doubles after each retry.
+	InitialBackoff time.Duration
+
+	// MaxBackoff is the maximum delay before a retry. A request is not retried if the datasource
+	// asks to retry it after a longer delay (Retry-After), in which case the delay is returned to SGNL.
+	MaxBackoff time.Duration
+}
+
+// ClientOption configures the Datasource returned by NewClient.
+type ClientOption func(*Datasource)
+
+// WithClientTimeout sets the overall timeout of the HTTP client, overriding the timeout in
+// seconds passed to NewClient, e.g. to set a sub-second timeout.
+func WithClientTimeout(timeout time.Duration) ClientOption {
+	return func(d *Datasource) {
+		d.Client.Timeout = timeout
+	}
+}
+
+// WithRequestTimeout sets the timeout of each HTTP request to the datasource.
+func WithRequestTimeout(timeout time.Duration) ClientOption {
+	return func(d *Datasource) {
+		d.RequestTimeout = timeout
+	}
+}
+
+// WithRetryPolicy sets the policy to retry failed HTTP requests to the datasource.
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(d *Datasource) {
+		d.RetryPolicy = policy
+	}
+}
+
+// WithRateLimit limits the HTTP requests to the datasource to requestsPerSecond on average, with
+// bursts of up to burst requests. The limit is shared by all requests sent by the client.
+func WithRateLimit(requestsPerSecond float64, burst int) ClientOption {
+	return func(d *Datasource) {
+		d.RateLimiter = rate.NewLimiter(rate.Limit(requestsPerSecond), burst)
+	}
+}
+
+// WithTransport sets the transport of the HTTP requests to the datasource, e.g. to trust the
+// certificate of a test server or to record and replay responses. Requests remain traced.
+func WithTransport(transport http.RoundTripper) ClientOption {
+	return func(d *Datasource) {
+		d.Client.Transport = otelhttp.NewTransport(transport)
+	}
+}
+
+// doRequest sends a GET request to the given datasource URL, waiting for the rate limiter and
+// retrying transient failures according to the RetryPolicy, and returns the response along with
+// the raw response body. The body is nil if the datasource did not return a successful status code.
+func (d *Datasource) doRequest(ctx context.Context, request *Request, requestURL string) (*Response, []byte, *framework.Error) {
+	backoff := d.RetryPolicy.InitialBackoff
+
+	for attempt := 1; ; attempt++ {
+		if d.RateLimiter != nil {
+			if err := d.RateLimiter.Wait(ctx); err != nil {
+				return nil, nil, &framework.Error{
+					Message: "Request to datasource was cancelled while waiting for the rate limit.",
+					Code:    api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_TEMPORARILY_UNAVAILABLE,
+				}
+			}
+		}
+
+		response, body, adapterErr := d.doRequestOnce(ctx, request, requestURL)
+		if adapterErr == nil || attempt >= d.RetryPolicy.MaxAttempts || !retryable(ctx, response, adapterErr) {
+			return response, body, adapterErr
+		}
+
+		delay := backoff
+		if adapterErr.RetryAfter != nil {
+			delay = *adapterErr.RetryAfter
+		}
+
+		if delay > d.RetryPolicy.MaxBackoff {
+			// Let SGNL retry the request after the delay requested by the datasource.
+			return response, body, adapterErr
+		}
+
+		logging.FromContext(ctx, d.Logger).InfoContext(ctx, "Retrying request to datasource",
+			slog.String("url", requestURL),
+			slog.Int("attempt", attempt),
+			slog.Duration("delay", delay),
+			slog.String("error", adapterErr.Message),
+		)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return response, body, adapterErr
+		case <-timer.C:
+		}
+
+		backoff = min(2*backoff, d.RetryPolicy.MaxBackoff)
+	}
+}
+
+// retryable returns true if the request failed with a transient error and can be retried.
+func retryable(ctx context.Context, response *Response, adapterErr *framework.Error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	if response == nil {
+		// The request could not be sent or its response could not be read.
+		return adapterErr.Code == api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_TEMPORARILY_UNAVAILABLE
+	}
+
+	switch response.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
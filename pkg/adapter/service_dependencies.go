@@ -0,0 +1,236 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+	api_adapter_v1 "github.com/sgnl-ai/adapter-framework/api/adapter/v1"
+)
+
+// ServiceReference is a reference to a PagerDuty service in a service dependency.
+type ServiceReference struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+}
+
+// ServiceRelationship is a single dependency between a supporting (technical or business)
+// service and a dependent business service.
+type ServiceRelationship struct {
+	ID                string           `json:"id"`
+	Type              string           `json:"type"`
+	SupportingService ServiceReference `json:"supporting_service"`
+	DependentService  ServiceReference `json:"dependent_service"`
+}
+
+// ServiceDependenciesResponse is the response of the
+// /service_dependencies/business_services/{id} endpoint.
+type ServiceDependenciesResponse struct {
+	Relationships []ServiceRelationship `json:"relationships"`
+}
+
+// getServiceDependenciesPage returns a page of at most PageSize service dependency edges.
+// PagerDuty does not list dependencies globally, so the business services are paged through and
+// the dependencies of each business service are queried. PagerDuty returns a dependency for both
+// of its services, so an edge is only returned for its dependent business service.
+// The cursor is the offset of the business service of the next edge, followed by the index of
+// that edge among the sorted edges of the business service if it is not the first, see
+// serviceDependenciesCursor.
+func (d *Datasource) getServiceDependenciesPage(ctx context.Context, request *Request) (*Response, *framework.Error) {
+	// Pages are filled up to PageSize, so a page of no edges would end the sync without any.
+	if request.PageSize <= 0 {
+		return nil, &framework.Error{
+			Message: fmt.Sprintf("Provided page size (%d) must be positive.", request.PageSize),
+			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_PAGE_REQUEST_CONFIG,
+		}
+	}
+
+	cursor, cursorErr := parseServiceDependenciesCursor(request.Cursor)
+	if cursorErr != nil {
+		return nil, &framework.Error{
+			Message: fmt.Sprintf("Provided cursor is invalid: %v.", cursorErr),
+			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_PAGE_REQUEST_CONFIG,
+		}
+	}
+
+	businessServicesRequest := *request
+	businessServicesRequest.EntityExternalID = BusinessServices
+	businessServicesRequest.Cursor = serviceDependenciesCursor{offset: cursor.offset}.String()
+
+	businessServices, err := d.getPage(ctx, &businessServicesRequest)
+	if err != nil || businessServices.StatusCode != http.StatusOK {
+		return businessServices, err
+	}
+
+	response := &Response{
+		StatusCode:       businessServices.StatusCode,
+		RetryAfterHeader: businessServices.RetryAfterHeader,
+		Objects:          make([]map[string]any, 0, request.PageSize),
+		NextCursor:       businessServices.NextCursor,
+	}
+
+	for i, businessService := range businessServices.Objects {
+		businessServiceID, ok := businessService["id"].(string)
+		if !ok || businessServiceID == "" {
+			return nil, &framework.Error{
+				Message: "Datasource returned a business service without an ID.",
+				Code:    api_adapter_v1.ErrorCode_ERROR_CODE_DATASOURCE_FAILED,
+			}
+		}
+
+		dependenciesURL := fmt.Sprintf("%s/%s/%s",
+			request.BaseURL,
+			ValidEntityExternalIDs[ServiceDependencies].endPoint,
+			url.PathEscape(businessServiceID),
+		)
+
+		dependencies, body, err := d.doRequest(ctx, request, dependenciesURL)
+		if err != nil || body == nil {
+			return dependencies, err
+		}
+
+		_, parseSpan := tracer.Start(ctx, "ParseServiceDependenciesResponse")
+		relationships, parseErr := ParseServiceDependenciesResponse(body)
+		endSpan(parseSpan, parseErr)
+
+		if parseErr != nil {
+			return nil, parseErr
+		}
+
+		relationships = dependentRelationships(relationships, businessServiceID)
+
+		// Only the first business service of the page may have been partially returned.
+		first := 0
+		if i == 0 {
+			first = cursor.edge
+		}
+
+		for edge := first; edge < len(relationships); edge++ {
+			if int64(len(response.Objects)) == request.PageSize {
+				response.NextCursor = serviceDependenciesCursor{offset: cursor.offset + i, edge: edge}.String()
+
+				return response, nil
+			}
+
+			response.Objects = append(response.Objects, relationships[edge].Edge())
+		}
+	}
+
+	return response, nil
+}
+
+// dependentRelationships returns the relationships in which the business service is the dependent
+// service, sorted by supporting service, so that their order is the same in every request.
+func dependentRelationships(relationships []ServiceRelationship, businessServiceID string) []ServiceRelationship {
+	dependent := make([]ServiceRelationship, 0, len(relationships))
+
+	for _, relationship := range relationships {
+		if relationship.DependentService.ID == businessServiceID {
+			dependent = append(dependent, relationship)
+		}
+	}
+
+	sort.SliceStable(dependent, func(i, j int) bool {
+		a, b := dependent[i].SupportingService, dependent[j].SupportingService
+		if a.ID != b.ID {
+			return a.ID < b.ID
+		}
+
+		return a.Type < b.Type
+	})
+
+	return dependent
+}
+
+// serviceDependenciesCursor is the position of the next edge of a service dependencies sync.
+type serviceDependenciesCursor struct {
+	// offset is the offset of the business service of the next edge.
+	offset int
+
+	// edge is the index of the next edge among the edges of the business service.
+	edge int
+}
+
+// parseServiceDependenciesCursor parses a cursor formatted as "<offset>" or "<offset>:<edge>".
+// An empty cursor is the first edge of the first business service.
+func parseServiceDependenciesCursor(cursor string) (serviceDependenciesCursor, error) {
+	var parsed serviceDependenciesCursor
+
+	if cursor == "" {
+		return parsed, nil
+	}
+
+	offset, edge, hasEdge := strings.Cut(cursor, ":")
+
+	var err error
+	if parsed.offset, err = strconv.Atoi(offset); err != nil || parsed.offset < 0 {
+		return parsed, fmt.Errorf("invalid business service offset %q", offset)
+	}
+
+	if hasEdge {
+		if parsed.edge, err = strconv.Atoi(edge); err != nil || parsed.edge < 0 {
+			return parsed, fmt.Errorf("invalid edge index %q", edge)
+		}
+	}
+
+	return parsed, nil
+}
+
+// String returns the cursor, or an empty string for the first edge of the first business service.
+func (c serviceDependenciesCursor) String() string {
+	switch {
+	case c.edge > 0:
+		return fmt.Sprintf("%d:%d", c.offset, c.edge)
+	case c.offset > 0:
+		return strconv.Itoa(c.offset)
+	default:
+		return ""
+	}
+}
+
+// Edge returns the relationship as an object with flat supporting and dependent service attributes.
+// The edge has no "id" attribute: its unique ID is synthesized from the services it connects, since
+// the relationship ID is not guaranteed to be stable when a dependency is re-created.
+func (r ServiceRelationship) Edge() map[string]any {
+	return map[string]any{
+		"type":                    r.Type,
+		"supporting_service_id":   r.SupportingService.ID,
+		"supporting_service_type": r.SupportingService.Type,
+		"dependent_service_id":    r.DependentService.ID,
+		"dependent_service_type":  r.DependentService.Type,
+	}
+}
+
+// ParseServiceDependenciesResponse parses the relationships returned by PagerDuty.
+func ParseServiceDependenciesResponse(body []byte) ([]ServiceRelationship, *framework.Error) {
+	var data ServiceDependenciesResponse
+
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, &framework.Error{
+			Message: fmt.Sprintf("Failed to unmarshal the datasource response: %v.", err),
+			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INTERNAL,
+		}
+	}
+
+	return data.Relationships, nil
+}
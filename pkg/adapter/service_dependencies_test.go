@@ -0,0 +1,122 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter_test
+
+import (
+	"context"
+	"testing"
+
+	api_adapter_v1 "github.com/sgnl-ai/adapter-framework/api/adapter/v1"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/adapter"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/adaptertest"
+)
+
+func TestGetServiceDependenciesPage(t *testing.T) {
+	mock, client := adaptertest.NewMockClient(t, nil)
+	fixtures := mock.Fixtures
+
+	want := make(map[string]bool)
+	for _, relationship := range fixtures["service_dependencies"] {
+		supporting := relationship["supporting_service"].(map[string]any)["id"].(string)
+		dependent := relationship["dependent_service"].(map[string]any)["id"].(string)
+		want[supporting+"->"+dependent] = true
+	}
+
+	for _, pageSize := range []int64{1, 2, 3, 25} {
+		got := make(map[string]bool)
+		cursor := ""
+
+		for page := 0; ; page++ {
+			if page > 100 {
+				t.Fatalf("page size %d: cursors don't terminate", pageSize)
+			}
+
+			response, err := client.GetPage(context.Background(), &adapter.Request{
+				BaseURL:          mock.URL,
+				Token:            "Token token=test",
+				EntityExternalID: adapter.ServiceDependencies,
+				PageSize:         pageSize,
+				Cursor:           cursor,
+			})
+			if err != nil {
+				t.Fatalf("page size %d, page %d: %s", pageSize, page, err.Message)
+			}
+
+			if int64(len(response.Objects)) > pageSize {
+				t.Errorf("page size %d, page %d: got %d edges", pageSize, page, len(response.Objects))
+			}
+
+			for _, edge := range response.Objects {
+				key := edge["supporting_service_id"].(string) + "->" + edge["dependent_service_id"].(string)
+				if got[key] {
+					t.Errorf("page size %d, page %d: edge %s returned twice", pageSize, page, key)
+				}
+
+				got[key] = true
+			}
+
+			if cursor = response.NextCursor; cursor == "" {
+				break
+			}
+		}
+
+		if len(got) != len(want) {
+			t.Errorf("page size %d: got %d edges, want %d", pageSize, len(got), len(want))
+		}
+
+		for edge := range want {
+			if !got[edge] {
+				t.Errorf("page size %d: edge %s is missing", pageSize, edge)
+			}
+		}
+	}
+}
+
+func TestGetServiceDependenciesPageInvalidCursor(t *testing.T) {
+	mock, client := adaptertest.NewMockClient(t, nil)
+
+	for _, cursor := range []string{"x", "-1", "3:x", "3:-2"} {
+		_, err := client.GetPage(context.Background(), &adapter.Request{
+			BaseURL:          mock.URL,
+			Token:            "Token token=test",
+			EntityExternalID: adapter.ServiceDependencies,
+			PageSize:         3,
+			Cursor:           cursor,
+		})
+		if err == nil {
+			t.Errorf("cursor %q: got no error", cursor)
+		}
+	}
+}
+
+func TestGetServiceDependenciesPageInvalidPageSize(t *testing.T) {
+	mock, client := adaptertest.NewMockClient(t, nil)
+
+	for _, pageSize := range []int64{0, -1} {
+		response, err := client.GetPage(context.Background(), &adapter.Request{
+			BaseURL:          mock.URL,
+			Token:            "Token token=test",
+			EntityExternalID: adapter.ServiceDependencies,
+			PageSize:         pageSize,
+		})
+		if err == nil || err.Code != api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_PAGE_REQUEST_CONFIG {
+			t.Errorf("page size %d: got response %+v and error %v, want an invalid page request config", pageSize, response, err)
+		}
+	}
+
+	if requests := mock.Requests(); len(requests) != 0 {
+		t.Errorf("Got %d requests to the datasource, want none", len(requests))
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+)
+
+// syntheticIDAttributes returns the attributes used to synthesize the unique ID of the objects of
+// the given entity. The entity's attributes can be overridden in the Config.
+// Returns nil if the entity's objects have a natural unique ID.
+func syntheticIDAttributes(config *Config, entityExternalID string) []string {
+	if config != nil {
+		if attributes, found := config.SyntheticIDAttributes[entityExternalID]; found {
+			return attributes
+		}
+	}
+
+	return ValidEntityExternalIDs[entityExternalID].syntheticIDAttributes
+}
+
+// SynthesizeID derives a stable, deterministic ID for the object from the values of the given
+// attributes. Nested attributes are addressed with "." delimited paths, e.g. "user.id".
+// Missing attributes are hashed as null values, so objects that only differ by missing
+// attributes get the same ID.
+func SynthesizeID(object map[string]any, attributes []string) string {
+	values := make([]any, 0, len(attributes))
+
+	for _, attribute := range attributes {
+		values = append(values, lookupAttribute(object, attribute))
+	}
+
+	// json.Marshal sorts map keys, which keeps the encoding of nested objects deterministic.
+	encoded, err := json.Marshal(values)
+	if err != nil {
+		// Values unmarshaled from a JSON response can always be marshaled back.
+		panic(err)
+	}
+
+	sum := sha256.Sum256(encoded)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// injectSyntheticIDs sets the uniqueIDAttribute of each object to the ID synthesized from the
+// given attributes.
+func injectSyntheticIDs(objects []map[string]any, uniqueIDAttribute string, attributes []string) {
+	for _, object := range objects {
+		object[uniqueIDAttribute] = SynthesizeID(object, attributes)
+	}
+}
+
+// lookupAttribute returns the value of the "." delimited attribute path in the object, or nil if
+// the attribute does not exist.
+func lookupAttribute(object map[string]any, path string) any {
+	var value any = object
+
+	for _, key := range strings.Split(path, ".") {
+		nested, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+
+		value = nested[key]
+	}
+
+	return value
+}
@@ -0,0 +1,86 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter_test
+
+import (
+	"testing"
+
+	"github.com/tksarunachalam/sgnl-adapter/pkg/adapter"
+)
+
+func TestSynthesizeID(t *testing.T) {
+	attributes := []string{"escalation_policy.id", "escalation_level", "schedule.id", "user.id", "start"}
+
+	// Numbers are float64, as unmarshaled from the datasource response.
+	onCall := func(policyID string, level float64) map[string]any {
+		return map[string]any{
+			"escalation_policy": map[string]any{"id": policyID, "summary": "Default"},
+			"escalation_level":  level,
+			"schedule":          nil,
+			"user":              map[string]any{"id": "PU00001", "summary": "Ada"},
+			"start":             "2023-01-01T00:00:00Z",
+		}
+	}
+
+	id := adapter.SynthesizeID(onCall("PE00001", 1), attributes)
+
+	if len(id) != 64 {
+		t.Errorf("got ID %q, want a hex SHA-256 digest", id)
+	}
+
+	if again := adapter.SynthesizeID(onCall("PE00001", 1), attributes); again != id {
+		t.Errorf("got ID %q for the same object, want %q", again, id)
+	}
+
+	// Attributes that are not hashed don't change the ID.
+	renamed := onCall("PE00001", 1)
+	renamed["user"].(map[string]any)["summary"] = "Ada Lovelace"
+
+	if got := adapter.SynthesizeID(renamed, attributes); got != id {
+		t.Errorf("got ID %q after an attribute that is not hashed changed, want %q", got, id)
+	}
+
+	if got := adapter.SynthesizeID(onCall("PE00002", 1), attributes); got == id {
+		t.Error("objects with another escalation policy got the same ID")
+	}
+
+	if got := adapter.SynthesizeID(onCall("PE00001", 2), attributes); got == id {
+		t.Error("objects with another escalation level got the same ID")
+	}
+}
+
+func TestSynthesizeIDMissingAttributes(t *testing.T) {
+	attributes := []string{"supporting_service_id", "dependent_service_id"}
+
+	missing := adapter.SynthesizeID(map[string]any{"supporting_service_id": "PS00001"}, attributes)
+	null := adapter.SynthesizeID(map[string]any{"supporting_service_id": "PS00001", "dependent_service_id": nil}, attributes)
+
+	if missing != null {
+		t.Errorf("got ID %q for a missing attribute, want the ID of a null attribute %q", missing, null)
+	}
+
+	// A path through a value that is not an object is missing too.
+	nested := []string{"user.id"}
+
+	if got, want := adapter.SynthesizeID(map[string]any{"user": "PU00001"}, nested), adapter.SynthesizeID(map[string]any{}, nested); got != want {
+		t.Errorf("got ID %q for a path through a string, want the ID of a missing attribute %q", got, want)
+	}
+
+	// Values are hashed in the order of the attributes, so swapped values get another ID.
+	swapped := adapter.SynthesizeID(map[string]any{"supporting_service_id": "PB00001", "dependent_service_id": "PS00001"}, attributes)
+	if unswapped := adapter.SynthesizeID(map[string]any{"supporting_service_id": "PS00001", "dependent_service_id": "PB00001"}, attributes); swapped == unswapped {
+		t.Error("objects with swapped values got the same ID")
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	framework "github.com/sgnl-ai/adapter-framework"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer creates the spans of the adapter. Spans are only recorded once a tracer provider is
+// registered, see the tracing package.
+var tracer = otel.Tracer("github.com/tksarunachalam/sgnl-adapter/pkg/adapter")
+
+// entityAttribute returns the span attribute of the requested entity.
+func entityAttribute(entityExternalID string) attribute.KeyValue {
+	return attribute.String("sgnl.entity", entityExternalID)
+}
+
+// endSpan ends the span, marking it as failed if err is not nil.
+func endSpan(span trace.Span, err *framework.Error) {
+	if err != nil {
+		span.SetAttributes(attribute.String("sgnl.error_code", err.Code.String()))
+		span.SetStatus(codes.Error, err.Message)
+	}
+
+	span.End()
+}
@@ -17,13 +17,16 @@ package adapter
 import (
 	"context"
 	"fmt"
+	"strings"
 
+	"github.com/PaesslerAG/jsonpath"
 	framework "github.com/sgnl-ai/adapter-framework"
 	api_adapter_v1 "github.com/sgnl-ai/adapter-framework/api/adapter/v1"
 )
 
 const (
-	// MaxPageSize is the maximum page size allowed in a GetPage request.
+	// MaxPageSize is the maximum page size allowed in a GetPage request, for entities that don't
+	// define their own, see Entity.MaxPageSize.
 	//
 	// SCAFFOLDING #7 - pkg/adapter/validation.go: Update this limit to match the limit of the SoR.
 	MaxPageSize = 100
@@ -54,12 +57,32 @@ func (a *Adapter) ValidateGetPageRequest(ctx context.Context, request *framework
 		}
 	}
 
+	if !a.servesEntity(request.Entity.ExternalId) {
+		return &framework.Error{
+			Message: fmt.Sprintf("Provided entity external ID %q is not served by this adapter type.", request.Entity.ExternalId),
+			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_ENTITY_CONFIG,
+		}
+	}
+
+	// PagerDuty filters incidents by creation time only, so a delta sync would miss their updates,
+	// e.g. resolutions.
+	if request.Config.Since != "" && ValidEntityExternalIDs[request.Entity.ExternalId].changesAfterCreation {
+		return &framework.Error{
+			Message: fmt.Sprintf("Incremental sync is not supported for entity %s, whose records change after they "+
+				"are created. Remove since from the config to fully sync it.", request.Entity.ExternalId),
+			Code: api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_ENTITY_CONFIG,
+		}
+	}
+
 	// Validate that at least the unique ID attribute for the requested entity
 	// is requested.
 	var uniqueIDAttributeFound bool
 
+	uniqueIDAttrExternalID := ValidEntityExternalIDs[request.Entity.ExternalId].uniqueIDAttrExternalID
+
 	for _, attribute := range request.Entity.Attributes {
-		if attribute.ExternalId == ValidEntityExternalIDs[request.Entity.ExternalId].uniqueIDAttrExternalID {
+		if attribute.ExternalId == uniqueIDAttrExternalID ||
+			(jsonPathAttributeNamesEnabled(request.Config) && attribute.ExternalId == "$."+uniqueIDAttrExternalID) {
 			uniqueIDAttributeFound = true
 
 			break
@@ -83,23 +106,57 @@ func (a *Adapter) ValidateGetPageRequest(ctx context.Context, request *framework
 		}
 	}
 
+	// JSONPath attribute names are parsed as when the objects are converted, for invalid expressions
+	// to be reported as an invalid entity config instead of a failed conversion.
+	if jsonPathAttributeNamesEnabled(request.Config) {
+		for _, attribute := range request.Entity.Attributes {
+			if !strings.HasPrefix(attribute.ExternalId, "$") {
+				continue
+			}
+
+			if _, err := jsonpath.New(attribute.ExternalId); err != nil {
+				return &framework.Error{
+					Message: fmt.Sprintf(
+						"Requested attribute %q is not a valid JSONPath expression, string literals must be double-quoted: %v.",
+						attribute.ExternalId, err,
+					),
+					Code: api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_ENTITY_CONFIG,
+				}
+			}
+		}
+	}
+
 	// SCAFFOLDING #10 - pkg/adapter/validation.go: Check for Ordered responses.
 	// If the datasource doesn't support sorting results by unique ID
 	// attribute for the requested entity, check instead that Ordered is set to
-	// false.
-	if request.Ordered {
+	// false, unless the config accepts objects ordered within each page only.
+	if request.Ordered && !request.Config.OrderWithinPages {
 		return &framework.Error{
-			Message: "Ordered must be set to false for PagerDuty Requests.",
-			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_ENTITY_CONFIG,
+			Message: fmt.Sprintf("Ordered must be set to false for entity %s, which PagerDuty can't sort by unique ID, "+
+				"unless orderWithinPages is enabled in the config.", request.Entity.ExternalId),
+			Code: api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_ENTITY_CONFIG,
 		}
 	}
 
-	if request.PageSize > MaxPageSize {
+	// Empty pages would end syncs without any object, so they are rejected even with ClampPageSize.
+	if request.PageSize <= 0 {
 		return &framework.Error{
-			Message: fmt.Sprintf("Provided page size (%d) exceeds maximum (%d).", request.PageSize, MaxPageSize),
+			Message: fmt.Sprintf("Provided page size (%d) must be positive.", request.PageSize),
 			Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_PAGE_REQUEST_CONFIG,
 		}
 	}
 
+	// Oversized pages are rejected, unless the config allows reducing them to the entity's maximum.
+	if maxPageSize := ValidEntityExternalIDs[request.Entity.ExternalId].MaxPageSize(); request.PageSize > maxPageSize {
+		if !request.Config.ClampPageSize {
+			return &framework.Error{
+				Message: fmt.Sprintf("Provided page size (%d) exceeds maximum (%d).", request.PageSize, maxPageSize),
+				Code:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_PAGE_REQUEST_CONFIG,
+			}
+		}
+
+		request.PageSize = maxPageSize
+	}
+
 	return nil
 }
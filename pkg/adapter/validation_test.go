@@ -0,0 +1,176 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter_test
+
+import (
+	"context"
+	"testing"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+	api_adapter_v1 "github.com/sgnl-ai/adapter-framework/api/adapter/v1"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/adapter"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/logging"
+)
+
+func TestValidatePageSize(t *testing.T) {
+	tests := map[string]struct {
+		entity        string
+		pageSize      int64
+		clampPageSize bool
+
+		// wantPageSize is the page size of the validated request, or 0 if it is rejected.
+		wantPageSize int64
+	}{
+		"maximum": {
+			entity:       adapter.Users,
+			pageSize:     adapter.MaxPageSize,
+			wantPageSize: adapter.MaxPageSize,
+		},
+		"above_maximum_rejected": {
+			entity:   adapter.Users,
+			pageSize: adapter.MaxPageSize + 1,
+		},
+		"above_maximum_clamped": {
+			entity:        adapter.Users,
+			pageSize:      adapter.MaxPageSize + 1,
+			clampPageSize: true,
+			wantPageSize:  adapter.MaxPageSize,
+		},
+		"above_entity_maximum_rejected": {
+			entity:   adapter.ServiceDependencies,
+			pageSize: 26,
+		},
+		"above_entity_maximum_clamped": {
+			entity:        adapter.ServiceDependencies,
+			pageSize:      500,
+			clampPageSize: true,
+			wantPageSize:  25,
+		},
+		"entity_maximum_above_default": {
+			entity:       adapter.AuditRecords,
+			pageSize:     1000,
+			wantPageSize: 1000,
+		},
+		"below_maximum_with_clamp": {
+			entity:        adapter.Users,
+			pageSize:      10,
+			clampPageSize: true,
+			wantPageSize:  10,
+		},
+		"zero_rejected": {
+			entity: adapter.Users,
+		},
+		"zero_rejected_with_clamp": {
+			entity:        adapter.Users,
+			clampPageSize: true,
+		},
+		"negative_rejected": {
+			entity:   adapter.ServiceDependencies,
+			pageSize: -1,
+		},
+		"negative_rejected_with_clamp": {
+			entity:        adapter.ServiceDependencies,
+			pageSize:      -1,
+			clampPageSize: true,
+		},
+	}
+
+	a := &adapter.Adapter{Logger: logging.Discard()}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			request := &framework.Request[adapter.Config]{
+				Auth:     &framework.DatasourceAuthCredentials{HTTPAuthorization: "Token token=test"},
+				Config:   &adapter.Config{ClampPageSize: tt.clampPageSize},
+				Entity:   framework.EntityConfig{ExternalId: tt.entity, Attributes: []*framework.AttributeConfig{stringAttribute("id")}},
+				PageSize: tt.pageSize,
+			}
+
+			err := a.ValidateGetPageRequest(context.Background(), request)
+
+			if tt.wantPageSize == 0 {
+				if err == nil || err.Code != api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_PAGE_REQUEST_CONFIG {
+					t.Fatalf("Got error %v, want an invalid page request config", err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Got error %s: %s", err.Code, err.Message)
+			}
+
+			if request.PageSize != tt.wantPageSize {
+				t.Errorf("Got page size %d, want %d", request.PageSize, tt.wantPageSize)
+			}
+		})
+	}
+}
+
+func TestValidateIncrementalSync(t *testing.T) {
+	tests := map[string]struct {
+		entity string
+		since  string
+
+		// wantErr is the code of the expected error, or 0 if the request is valid.
+		wantErr api_adapter_v1.ErrorCode
+	}{
+		"log_entries_since": {
+			entity: adapter.LogEntries,
+			since:  "24h",
+		},
+		"audit_records_since": {
+			entity: adapter.AuditRecords,
+			since:  "2023-01-01T00:00:00Z",
+		},
+		"incidents_since_rejected": {
+			entity:  adapter.Incidents,
+			since:   "24h",
+			wantErr: api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_ENTITY_CONFIG,
+		},
+		"incidents_full_sync": {
+			entity: adapter.Incidents,
+		},
+		"unsupported_entity_since": {
+			entity: adapter.Users,
+			since:  "24h",
+		},
+	}
+
+	a := &adapter.Adapter{Logger: logging.Discard()}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := a.ValidateGetPageRequest(context.Background(), &framework.Request[adapter.Config]{
+				Auth:     &framework.DatasourceAuthCredentials{HTTPAuthorization: "Token token=test"},
+				Config:   &adapter.Config{Since: tt.since},
+				Entity:   framework.EntityConfig{ExternalId: tt.entity, Attributes: []*framework.AttributeConfig{stringAttribute("id")}},
+				PageSize: 10,
+			})
+
+			if tt.wantErr == 0 {
+				if err != nil {
+					t.Fatalf("Got error %s: %s", err.Code, err.Message)
+				}
+
+				return
+			}
+
+			if err == nil || err.Code != tt.wantErr {
+				t.Fatalf("Got error %v, want code %s", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+)
+
+// Watermarks stores the high-water marks of completed incremental syncs, so that the next sync of
+// the same entity only queries the records created since the previous one started.
+// SGNL doesn't pass the cursor of a completed sync to the next one, so the adapter keeps them.
+type Watermarks interface {
+	// Get returns the high-water mark stored for the key, and false if there is none.
+	Get(key string) (time.Time, bool)
+
+	// Set stores the high-water mark of the key.
+	Set(key string, mark time.Time)
+}
+
+// MemoryWatermarks is a Watermarks stored in memory. Marks are lost when the adapter restarts,
+// in which case syncs start again from the configured watermark.
+type MemoryWatermarks struct {
+	mu    sync.Mutex
+	marks map[string]time.Time
+}
+
+// NewMemoryWatermarks returns an empty MemoryWatermarks.
+func NewMemoryWatermarks() *MemoryWatermarks {
+	return &MemoryWatermarks{marks: make(map[string]time.Time)}
+}
+
+// Get returns the high-water mark stored for the key, and false if there is none.
+func (w *MemoryWatermarks) Get(key string) (time.Time, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	mark, found := w.marks[key]
+
+	return mark, found
+}
+
+// Set stores the high-water mark of the key.
+func (w *MemoryWatermarks) Set(key string, mark time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.marks[key] = mark
+}
+
+// watermarkKey returns the key of the high-water mark of the requested entity. Syncs from
+// different datasources or credentials, or of different attributes or configs, don't share marks:
+// a sync whose attributes or config changed starts from the configured watermark again.
+// The key is hashed, so that credentials are not kept in memory.
+func watermarkKey(request *framework.Request[Config]) string {
+	hash := sha256.New()
+
+	// Parts are delimited by a null byte, which none of them contains.
+	for _, part := range []string{request.Address, request.Auth.HTTPAuthorization} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+
+	// The entity and config are encoded the same way as by SGNL, which can't fail.
+	encoder := json.NewEncoder(hash)
+	_ = encoder.Encode(request.Entity)
+	_ = encoder.Encode(request.Config)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,217 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package adapterserver wires the adapter gRPC server from its configuration: TLS, the adapter
+// types, and the health and readiness services. It is used by cmd/adapter and by the in-process
+// test harness, see package adaptertest.
+package adapterserver
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	api_adapter_v1 "github.com/sgnl-ai/adapter-framework/api/adapter/v1"
+	"github.com/sgnl-ai/adapter-framework/server"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/adapter"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/logging"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/readiness"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/serverconfig"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/tlsreload"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// ReadinessService is the name of the gRPC health service that reports whether the adapter is ready
+// to serve requests. The empty service name reports whether the server is alive.
+const ReadinessService = "readiness"
+
+// Server is the adapter gRPC server.
+type Server struct {
+	// GRPC is the gRPC server, which serves the adapter and health services.
+	GRPC *grpc.Server
+
+	// Health is the health service. Every service is NOT_SERVING until Serve is called.
+	Health *health.Server
+
+	config *serverconfig.Config
+	logger *slog.Logger
+
+	// stop stops the framework's and TLS files watchers, and the readiness checks.
+	stop chan struct{}
+
+	shutdown sync.Once
+}
+
+// New returns a server configured by config, which must be valid, whose adapter types query the
+// datasource with a client configured by config and by clientOpts.
+// The AUTH_TOKENS_PATH environment variable must be set to the path of the auth tokens file.
+// If logger is nil, logs are discarded.
+func New(config *serverconfig.Config, logger *slog.Logger, clientOpts ...adapter.ClientOption) (*Server, error) {
+	if logger == nil {
+		logger = logging.Discard()
+	}
+
+	s := &Server{
+		config: config,
+		logger: logger,
+		stop:   make(chan struct{}),
+	}
+
+	// SCAFFOLDING #1 - pkg/adapterserver/server.go: Pass options to configure TLS, connection parameters.
+	serverOpts := []grpc.ServerOption{
+		// Traces every gRPC request, continuing the trace propagated by the caller, if any.
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+	}
+
+	if config.TLS.CertFile != "" {
+		reloader, err := tlsreload.New(config.TLS.CertFile, config.TLS.KeyFile, config.TLS.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load TLS configuration: %w", err)
+		}
+
+		go reloader.Watch(s.stop, config.TLS.ReloadInterval, func(err error) {
+			logger.Error("Failed to reload TLS configuration, keeping the previous one", slog.Any("error", err))
+		})
+
+		serverOpts = append(serverOpts, grpc.Creds(credentials.NewTLS(reloader.TLSConfig())))
+	}
+
+	s.GRPC = grpc.NewServer(serverOpts...)
+
+	adapterServer := server.New(s.stop)
+
+	opts := []adapter.ClientOption{
+		adapter.WithRequestTimeout(config.Timeouts.Request),
+		adapter.WithRetryPolicy(adapter.RetryPolicy{
+			MaxAttempts:    config.Retry.MaxAttempts,
+			InitialBackoff: config.Retry.InitialBackoff,
+			MaxBackoff:     config.Retry.MaxBackoff,
+		}),
+	}
+
+	if config.RateLimit.RequestsPerSecond > 0 {
+		opts = append(opts, adapter.WithRateLimit(config.RateLimit.RequestsPerSecond, config.RateLimit.Burst))
+	}
+
+	// Every adapter type shares the client, and so its rate limit.
+	client := adapter.NewClient(int(config.Timeouts.HTTPClient/time.Second), logger, append(opts, clientOpts...)...)
+
+	// SCAFFOLDING #2 - pkg/adapterserver/server.go: Update Adapter type.
+	// The Adapter types below must be unique across all registered Adapters and match the Adapter
+	// type configured on the Adapter object via the SGNL Config API.
+	//
+	// The registered types are listed in the configuration, to run several versions side by side.
+	for _, adapterType := range config.Adapters {
+		defaultConfig, err := adapterType.AdapterConfig()
+		if err != nil {
+			return nil, fmt.Errorf("invalid config of adapter type %s: %w", adapterType.Type, err)
+		}
+
+		adapterOpts := []adapter.Option{adapter.WithDefaultConfig(defaultConfig)}
+		if len(adapterType.Entities) > 0 {
+			adapterOpts = append(adapterOpts, adapter.WithEntities(adapterType.Entities...))
+		}
+
+		adapterLogger := logger.With(slog.String("adapter_type", adapterType.Type))
+
+		err = server.RegisterAdapter(adapterServer, adapterType.Type, adapter.NewAdapter(client, adapterLogger, adapterOpts...))
+		if err != nil {
+			return nil, fmt.Errorf("failed to register adapter type %s: %w", adapterType.Type, err)
+		}
+
+		logger.Info("Registered adapter type", slog.String("adapter_type", adapterType.Type))
+	}
+
+	api_adapter_v1.RegisterAdapterServer(s.GRPC, adapterServer)
+
+	// Every service is NOT_SERVING until the server is started.
+	s.Health = health.NewServer()
+
+	for _, service := range append([]string{""}, readinessServices...) {
+		s.Health.SetServingStatus(service, healthpb.HealthCheckResponse_NOT_SERVING)
+	}
+
+	healthpb.RegisterHealthServer(s.GRPC, s.Health)
+
+	return s, nil
+}
+
+// readinessServices are the health services that report whether the adapter is ready.
+var readinessServices = []string{ReadinessService, api_adapter_v1.Adapter_ServiceDesc.ServiceName}
+
+// Serve reports the server as alive, starts the readiness checks and serves gRPC requests on the
+// listener until Shutdown is called.
+func (s *Server) Serve(listener net.Listener) error {
+	s.Health.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+
+	prober := &readiness.Prober{
+		Health:   s.Health,
+		Services: readinessServices,
+		Interval: s.config.Readiness.Interval,
+		Timeout:  5 * time.Second,
+		OnError: func(err error) {
+			s.logger.Warn("Readiness check failed", slog.Any("error", err))
+		},
+	}
+
+	if s.config.Readiness.Deep {
+		prober.Checks = []readiness.Check{
+			readiness.AuthTokensCheck(os.Getenv("AUTH_TOKENS_PATH")),
+			readiness.DNSCheck(s.config.Readiness.DNSHost),
+		}
+	}
+
+	go prober.Run(s.stop)
+
+	return s.GRPC.Serve(listener)
+}
+
+// Shutdown reports every service as NOT_SERVING for load balancers to stop sending new requests,
+// stops the server from accepting new requests and waits for in-flight requests to complete. If
+// they don't complete within the shutdown timeout, they are cancelled.
+// Calls after the first are no-ops, e.g. from the cleanup of the test harness.
+func (s *Server) Shutdown() {
+	s.shutdown.Do(s.drain)
+}
+
+func (s *Server) drain() {
+	s.Health.Shutdown()
+
+	close(s.stop)
+
+	drained := make(chan struct{})
+
+	go func() {
+		s.GRPC.GracefulStop()
+		close(drained)
+	}()
+
+	timer := time.NewTimer(s.config.Timeouts.Shutdown)
+	defer timer.Stop()
+
+	select {
+	case <-drained:
+	case <-timer.C:
+		s.logger.Warn("In-flight requests not drained, cancelling them", slog.Duration("timeout", s.config.Timeouts.Shutdown))
+		s.GRPC.Stop()
+		<-drained
+	}
+}
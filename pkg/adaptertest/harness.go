@@ -0,0 +1,197 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package adaptertest provides an in-process harness to test the adapter end to end: gRPC GetPage
+// calls go through the server wired by package adapterserver (as in cmd/adapter), the framework's
+// authentication and RegisterAdapter, validation, the Datasource and web.ConvertJSONObjectList, to
+// a fake PagerDuty API served by package pagerdutymock.
+//
+//	func TestUsers(t *testing.T) {
+//		h := adaptertest.New(t, adaptertest.Options{})
+//
+//		objects := h.MustGetAllPages(t, h.Request("users", "id", "email"))
+//		adaptertest.RequireUniqueIDs(t, objects, "id")
+//	}
+package adaptertest
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	api_adapter_v1 "github.com/sgnl-ai/adapter-framework/api/adapter/v1"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/adapter"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/adapterserver"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/pagerdutymock"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/serverconfig"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const (
+	// Token is the token that authenticates the gRPC requests of the harness to the adapter.
+	Token = "adaptertest-token"
+
+	// DatasourceToken is the authorization sent by the adapter to the fake datasource.
+	DatasourceToken = "Token token=adaptertest"
+
+	// DefaultAdapterType is the adapter type of the requests built by Request, unless overridden.
+	DefaultAdapterType = "Test-1.0.0"
+
+	bufferSize = 1 << 20
+)
+
+// Options configure the harness.
+type Options struct {
+	// Config is the configuration of the adapter server. TLS is ignored, since the harness
+	// connects over an in-memory plaintext connection.
+	// Defaults to serverconfig.Default(), with retry backoffs reduced to keep tests fast.
+	Config *serverconfig.Config
+
+	// Fixtures are the fixtures of the fake datasource.
+	// Defaults to pagerdutymock.DefaultFixtures(1). Ignored if Datasource is set.
+	Fixtures pagerdutymock.Fixtures
+
+	// Datasource is the handler of the fake datasource, served over HTTPS.
+	// Defaults to a pagerdutymock.Server serving Fixtures.
+	Datasource http.Handler
+
+	// ClientOptions are additional options of the client of the datasource.
+	ClientOptions []adapter.ClientOption
+}
+
+// Harness is an adapter server connected to a fake datasource, and a gRPC client of the server.
+type Harness struct {
+	// Client is the gRPC client of the adapter server. Requests are authenticated with Token.
+	Client api_adapter_v1.AdapterClient
+
+	// Health is the gRPC health client of the adapter server.
+	Health healthpb.HealthClient
+
+	// Mock is the fake datasource, or nil if Options.Datasource was set.
+	Mock *pagerdutymock.Server
+
+	// Fixtures are the fixtures served by Mock, to assert on the returned objects.
+	Fixtures pagerdutymock.Fixtures
+
+	// Datasource is the HTTPS server of the fake datasource.
+	Datasource *httptest.Server
+
+	// Server is the adapter server.
+	Server *adapterserver.Server
+
+	// AdapterType is the adapter type of the requests built by Request.
+	AdapterType string
+}
+
+// New starts an adapter server connected to a fake datasource, and returns a harness to send it
+// requests. The server, datasource and connection are stopped when the test completes.
+// New sets the AUTH_TOKENS_PATH environment variable for the test, so tests using it can't be
+// run in parallel.
+func New(t testing.TB, opts Options) *Harness {
+	t.Helper()
+
+	h := &Harness{AdapterType: DefaultAdapterType}
+
+	config := opts.Config
+	if config == nil {
+		config = serverconfig.Default()
+		config.Retry.InitialBackoff = 10 * time.Millisecond
+		config.Retry.MaxBackoff = 2 * time.Second
+	}
+
+	config.TLS = serverconfig.TLS{}
+
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Invalid adapter server configuration: %v", err)
+	}
+
+	if len(config.Adapters) > 0 {
+		h.AdapterType = config.Adapters[0].Type
+	}
+
+	datasource := opts.Datasource
+	if datasource == nil {
+		fixtures := opts.Fixtures
+		if fixtures == nil {
+			fixtures = pagerdutymock.DefaultFixtures(1)
+		}
+
+		h.Fixtures = fixtures
+		h.Mock = pagerdutymock.New(fixtures)
+		datasource = h.Mock
+	}
+
+	h.Datasource = httptest.NewTLSServer(datasource)
+	t.Cleanup(h.Datasource.Close)
+
+	// The framework reads the tokens that authenticate gRPC requests from AUTH_TOKENS_PATH.
+	tokensPath := filepath.Join(t.TempDir(), "tokens.json")
+	if err := os.WriteFile(tokensPath, []byte(`["`+Token+`"]`), 0o600); err != nil {
+		t.Fatalf("Failed to write auth tokens file: %v", err)
+	}
+
+	t.Setenv("AUTH_TOKENS_PATH", tokensPath)
+
+	clientOpts := append([]adapter.ClientOption{adapter.WithTransport(h.Datasource.Client().Transport)}, opts.ClientOptions...)
+
+	server, err := adapterserver.New(config, nil, clientOpts...)
+	if err != nil {
+		t.Fatalf("Failed to create adapter server: %v", err)
+	}
+
+	h.Server = server
+
+	listener := bufconn.Listen(bufferSize)
+
+	go func() {
+		_ = server.Serve(listener)
+	}()
+
+	t.Cleanup(server.Shutdown)
+
+	conn, err := grpc.Dial("bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(authenticate),
+	)
+	if err != nil {
+		t.Fatalf("Failed to connect to adapter server: %v", err)
+	}
+
+	// The connection is closed before the server is shut down, for the shutdown not to wait for it.
+	t.Cleanup(func() { conn.Close() })
+
+	h.Client = api_adapter_v1.NewAdapterClient(conn)
+	h.Health = healthpb.NewHealthClient(conn)
+
+	return h
+}
+
+// authenticate adds the token of the harness to the metadata of gRPC requests.
+func authenticate(
+	ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption,
+) error {
+	return invoker(metadata.AppendToOutgoingContext(ctx, "token", Token), method, req, reply, cc, opts...)
+}
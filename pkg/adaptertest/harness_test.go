@@ -0,0 +1,509 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adaptertest_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	api_adapter_v1 "github.com/sgnl-ai/adapter-framework/api/adapter/v1"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/adapterserver"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/adaptertest"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/pagerdutymock"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/serverconfig"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestEntities(t *testing.T) {
+	tests := map[string]struct {
+		// collection is the fixtures collection of the entity's objects.
+		collection string
+
+		// attribute is requested in addition to the unique ID, and compared to the value of each fixture.
+		attribute string
+		value     func(fixture map[string]any) string
+
+		// naturalID is true if the unique IDs are the IDs of the fixtures, rather than synthesized.
+		naturalID bool
+	}{
+		"teams": {
+			collection: "teams",
+			attribute:  "name",
+			value:      field("name"),
+			naturalID:  true,
+		},
+		"users": {
+			collection: "users",
+			attribute:  "email",
+			value:      field("email"),
+			naturalID:  true,
+		},
+		"vendors": {
+			collection: "vendors",
+			attribute:  "name",
+			value:      field("name"),
+			naturalID:  true,
+		},
+		"business_services": {
+			collection: "business_services",
+			attribute:  "name",
+			value:      field("name"),
+			naturalID:  true,
+		},
+		"service_dependencies": {
+			collection: "service_dependencies",
+			attribute:  "supporting_service_id",
+			value:      field("supporting_service", "id"),
+		},
+		"oncalls": {
+			collection: "oncalls",
+			attribute:  "start",
+			value:      field("start"),
+		},
+		"incidents": {
+			collection: "incidents",
+			attribute:  "status",
+			value:      field("status"),
+			naturalID:  true,
+		},
+		"log_entries": {
+			collection: "log_entries",
+			attribute:  "type",
+			value:      field("type"),
+			naturalID:  true,
+		},
+		"audit_records": {
+			collection: "audit_records",
+			attribute:  "action",
+			value:      field("action"),
+			naturalID:  true,
+		},
+	}
+
+	for entity, tt := range tests {
+		t.Run(entity, func(t *testing.T) {
+			h := adaptertest.New(t, adaptertest.Options{})
+
+			objects := h.MustGetAllPages(t, h.Request(entity, tt.attribute))
+			fixtures := h.Fixtures[tt.collection]
+
+			adaptertest.RequireCount(t, objects, len(fixtures))
+			adaptertest.RequireUniqueIDs(t, objects, "id")
+
+			var wantValues, wantIDs []string
+
+			for _, fixture := range fixtures {
+				wantValues = append(wantValues, tt.value(fixture))
+				wantIDs = append(wantIDs, fmt.Sprint(fixture["id"]))
+			}
+
+			if got := adaptertest.IDs(objects, tt.attribute); !sameStrings(got, wantValues) {
+				t.Errorf("Got %s values %v, want %v", tt.attribute, sorted(got), sorted(wantValues))
+			}
+
+			if tt.naturalID {
+				if got := adaptertest.IDs(objects, "id"); !sameStrings(got, wantIDs) {
+					t.Errorf("Got IDs %v, want %v", sorted(got), sorted(wantIDs))
+				}
+			}
+		})
+	}
+}
+
+func TestAdapterTypes(t *testing.T) {
+	config := testConfig()
+	config.Adapters = []serverconfig.AdapterType{
+		{Type: "PagerDuty-1.0.0", Entities: []string{"users", "teams"}},
+		{Type: "PagerDuty-2.0.0", Config: map[string]any{"clampPageSize": true}},
+	}
+
+	h := adaptertest.New(t, adaptertest.Options{Config: config})
+
+	tests := map[string]struct {
+		adapterType string
+		entity      string
+		pageSize    int64
+		wantCount   int
+		wantPages   int
+		wantCode    api_adapter_v1.ErrorCode
+		wantMessage string
+	}{
+		"served entity": {
+			adapterType: "PagerDuty-1.0.0",
+			entity:      "users",
+			pageSize:    25,
+			wantCount:   60,
+			wantPages:   3,
+		},
+		"entity not served by the type": {
+			adapterType: "PagerDuty-1.0.0",
+			entity:      "incidents",
+			pageSize:    25,
+			wantCode:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_ENTITY_CONFIG,
+			wantMessage: `Provided entity external ID "incidents" is not served by this adapter type.`,
+		},
+		"every entity served by an unrestricted type": {
+			adapterType: "PagerDuty-2.0.0",
+			entity:      "incidents",
+			pageSize:    25,
+			wantCount:   40,
+			wantPages:   2,
+		},
+		"page size exceeds maximum": {
+			adapterType: "PagerDuty-1.0.0",
+			entity:      "users",
+			pageSize:    500,
+			wantCode:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_PAGE_REQUEST_CONFIG,
+			wantMessage: "Provided page size (500) exceeds maximum (100).",
+		},
+		"page size clamped by the default config of the type": {
+			adapterType: "PagerDuty-2.0.0",
+			entity:      "users",
+			pageSize:    500,
+			wantCount:   60,
+			wantPages:   1,
+		},
+		"unknown type": {
+			adapterType: "PagerDuty-3.0.0",
+			entity:      "users",
+			pageSize:    25,
+			wantCode:    api_adapter_v1.ErrorCode_ERROR_CODE_INVALID_DATASOURCE_CONFIG,
+			wantMessage: "Unsupported datasource type provided: PagerDuty-3.0.0.",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			request := h.Request(tt.entity)
+			request.Datasource.Type = tt.adapterType
+			request.PageSize = tt.pageSize
+
+			objects, pages, err := h.GetAllPages(context.Background(), request)
+
+			if tt.wantCode != api_adapter_v1.ErrorCode_ERROR_CODE_UNSPECIFIED {
+				adapterErr, ok := err.(*adaptertest.Error)
+				if !ok {
+					t.Fatalf("Got error %v, want an adapter error", err)
+				}
+
+				if adapterErr.Err.Code != tt.wantCode || adapterErr.Err.Message != tt.wantMessage {
+					t.Errorf("Got error %s: %q, want %s: %q", adapterErr.Err.Code, adapterErr.Err.Message, tt.wantCode, tt.wantMessage)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Failed to get all pages: %v", err)
+			}
+
+			adaptertest.RequireCount(t, objects, tt.wantCount)
+			adaptertest.RequireUniqueIDs(t, objects, "id")
+
+			if pages != tt.wantPages {
+				t.Errorf("Got %d pages, want %d", pages, tt.wantPages)
+			}
+		})
+	}
+}
+
+func TestHealth(t *testing.T) {
+	services := []string{"", adapterserver.ReadinessService, api_adapter_v1.Adapter_ServiceDesc.ServiceName}
+
+	tests := map[string]struct {
+		readiness serverconfig.Readiness
+		want      map[string]healthpb.HealthCheckResponse_ServingStatus
+	}{
+		"ready": {
+			readiness: serverconfig.Readiness{Interval: time.Minute},
+			want: map[string]healthpb.HealthCheckResponse_ServingStatus{
+				"":                             healthpb.HealthCheckResponse_SERVING,
+				adapterserver.ReadinessService: healthpb.HealthCheckResponse_SERVING,
+				api_adapter_v1.Adapter_ServiceDesc.ServiceName: healthpb.HealthCheckResponse_SERVING,
+			},
+		},
+		"deep checks pass": {
+			readiness: serverconfig.Readiness{Deep: true, DNSHost: "localhost", Interval: time.Minute},
+			want: map[string]healthpb.HealthCheckResponse_ServingStatus{
+				"":                             healthpb.HealthCheckResponse_SERVING,
+				adapterserver.ReadinessService: healthpb.HealthCheckResponse_SERVING,
+				api_adapter_v1.Adapter_ServiceDesc.ServiceName: healthpb.HealthCheckResponse_SERVING,
+			},
+		},
+		"deep checks fail": {
+			// Host names can't contain spaces, so the host is not resolved.
+			readiness: serverconfig.Readiness{Deep: true, DNSHost: "no such host", Interval: time.Minute},
+			want: map[string]healthpb.HealthCheckResponse_ServingStatus{
+				"":                             healthpb.HealthCheckResponse_SERVING,
+				adapterserver.ReadinessService: healthpb.HealthCheckResponse_NOT_SERVING,
+				api_adapter_v1.Adapter_ServiceDesc.ServiceName: healthpb.HealthCheckResponse_NOT_SERVING,
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			config := testConfig()
+			config.Readiness = tt.readiness
+
+			h := adaptertest.New(t, adaptertest.Options{Config: config})
+
+			// The readiness checks run asynchronously once the server is started.
+			for _, service := range services {
+				requireStatus(t, h.Health, service, tt.want[service])
+			}
+
+			// Once shut down, every service is NOT_SERVING, which the health server reports in-process
+			// since the server no longer accepts requests.
+			h.Server.Shutdown()
+
+			for _, service := range services {
+				response, err := h.Server.Health.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+				if err != nil {
+					t.Fatalf("Failed to check health of service %q: %v", service, err)
+				}
+
+				if response.Status != healthpb.HealthCheckResponse_NOT_SERVING {
+					t.Errorf("Got status %s of service %q after shutdown, want NOT_SERVING", response.Status, service)
+				}
+			}
+		})
+	}
+}
+
+func TestHealthBeforeServe(t *testing.T) {
+	tokensPath := filepath.Join(t.TempDir(), "tokens.json")
+	if err := os.WriteFile(tokensPath, []byte(`["`+adaptertest.Token+`"]`), 0o600); err != nil {
+		t.Fatalf("Failed to write auth tokens file: %v", err)
+	}
+
+	t.Setenv("AUTH_TOKENS_PATH", tokensPath)
+
+	server, err := adapterserver.New(testConfig(), nil)
+	if err != nil {
+		t.Fatalf("Failed to create adapter server: %v", err)
+	}
+
+	t.Cleanup(server.Shutdown)
+
+	for _, service := range []string{"", adapterserver.ReadinessService, api_adapter_v1.Adapter_ServiceDesc.ServiceName} {
+		response, err := server.Health.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+		if err != nil {
+			t.Fatalf("Failed to check health of service %q: %v", service, err)
+		}
+
+		if response.Status != healthpb.HealthCheckResponse_NOT_SERVING {
+			t.Errorf("Got status %s of service %q before Serve, want NOT_SERVING", response.Status, service)
+		}
+	}
+}
+
+// TestRetry verifies that transient datasource failures are only retried if configured.
+func TestRetry(t *testing.T) {
+	tests := map[string]struct {
+		maxAttempts int
+
+		wantRequests int
+		wantErr      bool
+	}{
+		"not_retried_by_default": {
+			maxAttempts:  serverconfig.Default().Retry.MaxAttempts,
+			wantRequests: 1,
+			wantErr:      true,
+		},
+		"retried": {
+			maxAttempts:  3,
+			wantRequests: 3,
+		},
+		"attempts_exhausted": {
+			maxAttempts:  2,
+			wantRequests: 2,
+			wantErr:      true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			config := testConfig()
+			config.Retry.MaxAttempts = tt.maxAttempts
+
+			h := adaptertest.New(t, adaptertest.Options{Config: config})
+			h.Mock.AddFault(pagerdutymock.Fault{Status: http.StatusServiceUnavailable, Times: 2})
+
+			response, err := h.Client.GetPage(context.Background(), h.Request("users", "id"))
+			if err != nil {
+				t.Fatalf("Failed to get page: %v", err)
+			}
+
+			if got := response.GetError() != nil; got != tt.wantErr {
+				t.Errorf("Got error %v, want error %t", response.GetError(), tt.wantErr)
+			}
+
+			if got := len(h.Mock.Requests()); got != tt.wantRequests {
+				t.Errorf("Got %d requests to the datasource, want %d", got, tt.wantRequests)
+			}
+		})
+	}
+}
+
+// TestShutdown verifies that Shutdown waits for in-flight requests, and cancels those still running
+// after the shutdown timeout, e.g. stuck on a datasource that doesn't respond.
+func TestShutdown(t *testing.T) {
+	tests := map[string]struct {
+		// delay is the duration the datasource takes to respond.
+		delay    time.Duration
+		shutdown time.Duration
+
+		// wantErr is true if the in-flight request is cancelled.
+		wantErr bool
+	}{
+		"drained": {
+			delay:    200 * time.Millisecond,
+			shutdown: 10 * time.Second,
+		},
+		"stuck_request_cancelled": {
+			delay:    time.Minute,
+			shutdown: 100 * time.Millisecond,
+			wantErr:  true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			config := testConfig()
+			config.Timeouts.HTTPClient = 2 * time.Minute
+			config.Timeouts.Request = 2 * time.Minute
+			config.Timeouts.Shutdown = tt.shutdown
+
+			h := adaptertest.New(t, adaptertest.Options{Config: config})
+			h.Mock.AddFault(pagerdutymock.Fault{Delay: tt.delay})
+
+			type result struct {
+				response *api_adapter_v1.GetPageResponse
+				err      error
+			}
+
+			results := make(chan result, 1)
+
+			go func() {
+				response, err := h.Client.GetPage(context.Background(), h.Request("users", "id"))
+				results <- result{response, err}
+			}()
+
+			// The request is in flight once the datasource received it.
+			for deadline := time.Now().Add(5 * time.Second); len(h.Mock.Requests()) == 0; time.Sleep(10 * time.Millisecond) {
+				if time.Now().After(deadline) {
+					t.Fatal("The datasource received no request")
+				}
+			}
+
+			start := time.Now()
+			h.Server.Shutdown()
+
+			if elapsed := time.Since(start); elapsed > tt.shutdown+5*time.Second {
+				t.Errorf("Shutdown took %s, want at most the shutdown timeout %s", elapsed, tt.shutdown)
+			}
+
+			if tt.wantErr {
+				if got := <-results; got.err == nil && got.response.GetError() == nil {
+					t.Errorf("Got response %v, want the request to be cancelled", got.response)
+				}
+
+				return
+			}
+
+			var got result
+
+			select {
+			case got = <-results:
+			case <-time.After(time.Second):
+				t.Fatal("Shutdown returned before the in-flight request completed")
+			}
+
+			if got.err != nil || got.response.GetError() != nil {
+				t.Fatalf("Got error %v, %v, want the request to complete", got.err, got.response.GetError())
+			}
+
+			if len(got.response.GetSuccess().GetObjects()) == 0 {
+				t.Error("Got no objects from the drained request")
+			}
+		})
+	}
+}
+
+// testConfig returns the default configuration of the harness.
+func testConfig() *serverconfig.Config {
+	config := serverconfig.Default()
+	config.Retry.InitialBackoff = 10 * time.Millisecond
+	config.Retry.MaxBackoff = 2 * time.Second
+
+	return config
+}
+
+// requireStatus fails the test if the status of the service is not want within 5 seconds.
+func requireStatus(t *testing.T, client healthpb.HealthClient, service string, want healthpb.HealthCheckResponse_ServingStatus) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+
+	for {
+		response, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+		if err != nil {
+			t.Fatalf("Failed to check health of service %q: %v", service, err)
+		}
+
+		if response.Status == want {
+			return
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("Got status %s of service %q, want %s", response.Status, service, want)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// field returns the string value at the path of nested fields of a fixture.
+func field(path ...string) func(map[string]any) string {
+	return func(fixture map[string]any) string {
+		var value any = fixture
+
+		for _, name := range path {
+			object, _ := value.(map[string]any)
+			value = object[name]
+		}
+
+		return fmt.Sprint(value)
+	}
+}
+
+func sorted(values []string) []string {
+	values = append([]string(nil), values...)
+	sort.Strings(values)
+
+	return values
+}
+
+func sameStrings(a, b []string) bool {
+	return strings.Join(sorted(a), "\n") == strings.Join(sorted(b), "\n")
+}
@@ -0,0 +1,36 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adaptertest
+
+import (
+	"testing"
+
+	"github.com/tksarunachalam/sgnl-adapter/pkg/adapter"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/pagerdutymock"
+)
+
+// NewMockClient serves the fixtures, or pagerdutymock.DefaultFixtures(1) if nil, until the test
+// completes, and returns the fake datasource and a Datasource client of it, to test the adapter
+// without the gRPC server:
+//
+//	mock, client := adaptertest.NewMockClient(t, nil)
+//	a := &adapter.Adapter{Client: client, Logger: logging.Discard()}
+func NewMockClient(t testing.TB, fixtures pagerdutymock.Fixtures, opts ...adapter.ClientOption) (*pagerdutymock.TestServer, adapter.Client) {
+	t.Helper()
+
+	mock := pagerdutymock.NewTestServer(t, fixtures)
+
+	return mock, adapter.NewClient(10, nil, append([]adapter.ClientOption{adapter.WithTransport(mock.Client.Transport)}, opts...)...)
+}
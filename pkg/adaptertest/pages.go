@@ -0,0 +1,207 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adaptertest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	api_adapter_v1 "github.com/sgnl-ai/adapter-framework/api/adapter/v1"
+)
+
+// DefaultPageSize is the page size of the requests built by Request.
+const DefaultPageSize = 10
+
+// MaxPages is the maximum number of pages fetched by GetAllPages, to stop on cursor loops.
+const MaxPages = 1000
+
+// Request returns a GetPage request of the first page of the entity, with the given attributes
+// as strings, from the fake datasource. The unique ID attribute "id" is always requested.
+// The request can be modified before it is sent, e.g. to set Config, attribute types or PageSize.
+func (h *Harness) Request(entityExternalID string, attributes ...string) *api_adapter_v1.GetPageRequest {
+	requested := []*api_adapter_v1.AttributeConfig{{
+		Id:         "id",
+		ExternalId: "id",
+		Type:       api_adapter_v1.AttributeType_ATTRIBUTE_TYPE_STRING,
+	}}
+
+	for _, attribute := range attributes {
+		if attribute == "id" {
+			continue
+		}
+
+		requested = append(requested, &api_adapter_v1.AttributeConfig{
+			Id:         attribute,
+			ExternalId: attribute,
+			Type:       api_adapter_v1.AttributeType_ATTRIBUTE_TYPE_STRING,
+		})
+	}
+
+	return &api_adapter_v1.GetPageRequest{
+		Datasource: &api_adapter_v1.DatasourceConfig{
+			Id:      "adaptertest",
+			Type:    h.AdapterType,
+			Address: h.Datasource.URL,
+			Auth: &api_adapter_v1.DatasourceAuthCredentials{
+				AuthMechanism: &api_adapter_v1.DatasourceAuthCredentials_HttpAuthorization{
+					HttpAuthorization: DatasourceToken,
+				},
+			},
+			Config: []byte(`{}`),
+		},
+		Entity: &api_adapter_v1.EntityConfig{
+			Id:         entityExternalID,
+			ExternalId: entityExternalID,
+			Attributes: requested,
+		},
+		PageSize: DefaultPageSize,
+	}
+}
+
+// WithConfig sets the adapter config of the request, marshalled to JSON, and returns the request.
+func WithConfig(request *api_adapter_v1.GetPageRequest, config any) *api_adapter_v1.GetPageRequest {
+	raw, err := json.Marshal(config)
+	if err != nil {
+		panic(fmt.Sprintf("adaptertest: failed to marshal config: %v", err))
+	}
+
+	request.Datasource.Config = raw
+
+	return request
+}
+
+// Error is the error returned by the adapter in a GetPage response.
+type Error struct {
+	// Page is the index of the page that failed, from 0.
+	Page int
+
+	// Err is the error returned by the adapter.
+	Err *api_adapter_v1.Error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("page %d: %s: %s", e.Page, e.Err.Code, e.Err.Message)
+}
+
+// GetAllPages sends the request, then requests the following pages with the returned cursors
+// until the last page, and returns the objects of every page and the number of pages.
+// If the adapter returns an error, the objects of the previous pages are returned with an *Error.
+// The request's cursor is updated.
+func (h *Harness) GetAllPages(ctx context.Context, request *api_adapter_v1.GetPageRequest) ([]*api_adapter_v1.Object, int, error) {
+	var objects []*api_adapter_v1.Object
+
+	for page := 0; page < MaxPages; page++ {
+		response, err := h.Client.GetPage(ctx, request)
+		if err != nil {
+			return objects, page, fmt.Errorf("page %d: %w", page, err)
+		}
+
+		if response.GetError() != nil {
+			return objects, page, &Error{Page: page, Err: response.GetError()}
+		}
+
+		objects = append(objects, response.GetSuccess().GetObjects()...)
+
+		if response.GetSuccess().GetNextCursor() == "" {
+			return objects, page + 1, nil
+		}
+
+		request.Cursor = response.GetSuccess().GetNextCursor()
+	}
+
+	return objects, MaxPages, fmt.Errorf("more than %d pages returned", MaxPages)
+}
+
+// MustGetAllPages returns the objects of every page of the request, and fails the test if a page
+// fails.
+func (h *Harness) MustGetAllPages(t testing.TB, request *api_adapter_v1.GetPageRequest) []*api_adapter_v1.Object {
+	t.Helper()
+
+	objects, _, err := h.GetAllPages(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Failed to get all pages of %s: %v", request.GetEntity().GetExternalId(), err)
+	}
+
+	return objects
+}
+
+// StringValues returns the string values of the attribute of the object.
+func StringValues(object *api_adapter_v1.Object, attributeID string) []string {
+	var values []string
+
+	for _, attribute := range object.GetAttributes() {
+		if attribute.GetId() != attributeID {
+			continue
+		}
+
+		for _, value := range attribute.GetValues() {
+			values = append(values, value.GetStringValue())
+		}
+	}
+
+	return values
+}
+
+// StringValue returns the first string value of the attribute of the object, or an empty string
+// if the attribute has no value.
+func StringValue(object *api_adapter_v1.Object, attributeID string) string {
+	if values := StringValues(object, attributeID); len(values) > 0 {
+		return values[0]
+	}
+
+	return ""
+}
+
+// IDs returns the first string value of the attribute of every object, e.g. their unique IDs.
+func IDs(objects []*api_adapter_v1.Object, attributeID string) []string {
+	ids := make([]string, 0, len(objects))
+
+	for _, object := range objects {
+		ids = append(ids, StringValue(object, attributeID))
+	}
+
+	return ids
+}
+
+// RequireUniqueIDs fails the test if an object has no value of the unique ID attribute, or if
+// two objects have the same value.
+func RequireUniqueIDs(t testing.TB, objects []*api_adapter_v1.Object, attributeID string) {
+	t.Helper()
+
+	seen := make(map[string]int, len(objects))
+
+	for i, id := range IDs(objects, attributeID) {
+		if id == "" {
+			t.Fatalf("Object %d has no %s attribute", i, attributeID)
+		}
+
+		if previous, found := seen[id]; found {
+			t.Fatalf("Objects %d and %d have the same %s %q", previous, i, attributeID, id)
+		}
+
+		seen[id] = i
+	}
+}
+
+// RequireCount fails the test if the number of objects is not the expected count.
+func RequireCount(t testing.TB, objects []*api_adapter_v1.Object, count int) {
+	t.Helper()
+
+	if len(objects) != count {
+		t.Fatalf("Got %d objects, want %d", len(objects), count)
+	}
+}
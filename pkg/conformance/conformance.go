@@ -0,0 +1,347 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package conformance verifies that the adapter upholds the invariants SGNL relies on when it
+// syncs an entity, with any Client implementation, e.g. the Datasource against the fake API of
+// package pagerdutymock, or replaying a cassette of package httprecord:
+//
+//   - every object has a value of the entity's unique ID attribute,
+//   - cursors terminate, without returning a previous cursor,
+//   - no unique ID is returned twice across the pages of a sync,
+//   - pages never have more objects than the requested page size, nor than the entity's maximum,
+//   - a request with an empty cursor starts from the first page,
+//   - with Options.Ordered, objects are sorted by unique ID within each page, see
+//     adapter.Config.OrderWithinPages.
+//
+// Pages are requested with Adapter.GetPage, so that unique IDs synthesized by the adapter are
+// verified too.
+//
+//	func TestConformance(t *testing.T) {
+//		mock, client := adaptertest.NewMockClient(t, nil)
+//
+//		conformance.Run(t, client, conformance.Options{BaseURL: mock.URL, Token: "Token token=test"})
+//	}
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/adapter"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/logging"
+)
+
+// DefaultMaxPages is the default maximum number of pages of a sync, after which its cursors are
+// considered not to terminate.
+const DefaultMaxPages = 1000
+
+// MaxEntityPageSize is the page size of Options.PageSizes that stands for the maximum page size of
+// each entity, see adapter.Entity.MaxPageSize.
+const MaxEntityPageSize = 0
+
+// DefaultPageSizes are the default page sizes each entity is synced with: its maximum, and a small
+// size to span several pages.
+var DefaultPageSizes = []int64{MaxEntityPageSize, 3}
+
+// Options configure the suite.
+type Options struct {
+	// BaseURL is the address of the datasource, e.g. https://api.pagerduty.com.
+	BaseURL string
+
+	// Token is the Authorization header of the requests to the datasource.
+	Token string
+
+	// Entities are the external IDs of the verified entities.
+	// Defaults to every entity of adapter.ValidEntityExternalIDs.
+	Entities []string
+
+	// PageSizes are the page sizes each entity is synced with, or MaxEntityPageSize for the
+	// entity's maximum.
+	// Defaults to DefaultPageSizes.
+	PageSizes []int64
+
+	// Config is the adapter config of the requests.
+	// Optional.
+	Config *adapter.Config
+
+	// MaxPages is the maximum number of pages of a sync.
+	// Defaults to DefaultMaxPages.
+	MaxPages int
+
+	// Ordered requests objects ordered by unique ID, and verifies their order.
+	// PagerDuty can't sort objects, so requests fail unless Config enables OrderWithinPages.
+	Ordered bool
+}
+
+// Violation is an invariant that doesn't hold for a sync.
+type Violation struct {
+	Entity   string
+	PageSize int64
+
+	// Page is the index of the page, from 0, that violates the invariant.
+	Page int
+
+	Message string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s (page size %d), page %d: %s", v.Entity, v.PageSize, v.Page, v.Message)
+}
+
+// Run verifies the invariants of every entity with the client, in a subtest per entity and page
+// size, and reports every violation as a test error.
+func Run(t *testing.T, client adapter.Client, opts Options) {
+	t.Helper()
+
+	opts = opts.withDefaults()
+
+	for _, entity := range opts.Entities {
+		for _, pageSize := range opts.PageSizes {
+			name := fmt.Sprintf("%s/page_size=%d", entity, pageSize)
+			if pageSize == MaxEntityPageSize {
+				name = entity + "/page_size=max"
+			}
+
+			t.Run(name, func(t *testing.T) {
+				violations, err := Check(context.Background(), client, entity, pageSize, opts)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				for _, violation := range violations {
+					t.Error(violation)
+				}
+			})
+		}
+	}
+}
+
+// Check syncs the entity with the client with pages of pageSize objects, or of the entity's maximum
+// for MaxEntityPageSize, then requests its first page again, and returns the violated invariants.
+// An error is returned if a page fails.
+func Check(ctx context.Context, client adapter.Client, entityExternalID string, pageSize int64, opts Options) ([]Violation, error) {
+	opts = opts.withDefaults()
+
+	entity, found := adapter.ValidEntityExternalIDs[entityExternalID]
+	if !found {
+		return nil, fmt.Errorf("unknown entity %q", entityExternalID)
+	}
+
+	uniqueID := entity.UniqueIDAttrExternalID()
+
+	if pageSize == MaxEntityPageSize {
+		pageSize = entity.MaxPageSize()
+	}
+
+	var violations []Violation
+
+	violate := func(page int, format string, args ...any) {
+		violations = append(violations, Violation{
+			Entity:   entityExternalID,
+			PageSize: pageSize,
+			Page:     page,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	// The pages in which each unique ID and cursor was returned.
+	seenIDs := make(map[string]int)
+	seenCursors := make(map[string]int)
+
+	var firstPage []string
+
+	cursor := ""
+
+	for page := 0; ; page++ {
+		if page == opts.MaxPages {
+			violate(page, "cursors don't terminate after %d pages", opts.MaxPages)
+
+			break
+		}
+
+		objects, nextCursor, err := getPage(ctx, client, entityExternalID, uniqueID, pageSize, cursor, opts)
+		if err != nil {
+			return violations, fmt.Errorf("%s (page size %d), page %d: %w", entityExternalID, pageSize, page, err)
+		}
+
+		if len(objects) > int(pageSize) {
+			violate(page, "%d objects exceed the page size", len(objects))
+		}
+
+		if len(objects) > int(entity.MaxPageSize()) {
+			violate(page, "%d objects exceed the maximum page size %d", len(objects), entity.MaxPageSize())
+		}
+
+		ids := uniqueIDs(objects, uniqueID)
+
+		for i, id := range ids {
+			if id == "" {
+				violate(page, "object %d has no string %s attribute", i, uniqueID)
+
+				continue
+			}
+
+			if previous, found := seenIDs[id]; found {
+				violate(page, "%s %q was already returned in page %d", uniqueID, id, previous)
+			}
+
+			seenIDs[id] = page
+		}
+
+		if opts.Ordered {
+			for i, id := range ids {
+				if i > 0 && id < ids[i-1] {
+					violate(page, "%s %q is not ordered after %q", uniqueID, id, ids[i-1])
+				}
+			}
+		}
+
+		if page == 0 {
+			firstPage = ids
+		}
+
+		if nextCursor == "" {
+			break
+		}
+
+		if previous, found := seenCursors[nextCursor]; found {
+			violate(page, "cursor %q was already returned by page %d", nextCursor, previous)
+
+			break
+		}
+
+		seenCursors[nextCursor] = page
+		cursor = nextCursor
+	}
+
+	// A sync that starts over, e.g. after a full sync completed, must not resume from the last page.
+	objects, _, err := getPage(ctx, client, entityExternalID, uniqueID, pageSize, "", opts)
+	if err != nil {
+		return violations, fmt.Errorf("%s (page size %d), first page requested again: %w", entityExternalID, pageSize, err)
+	}
+
+	if again := uniqueIDs(objects, uniqueID); !sameIDs(again, firstPage) {
+		violate(0, "requested again with an empty cursor, the first page has other objects: %v, want %v", again, firstPage)
+	}
+
+	return violations, nil
+}
+
+// getPage requests a page of the entity, with only its unique ID attribute, from the adapter, and
+// returns the objects returned by the client.
+// The framework leaves out converted objects without any requested attribute, so the invariants
+// are verified on the client's objects, once the adapter synthesized their unique IDs, if needed.
+func getPage(
+	ctx context.Context, client adapter.Client, entityExternalID, uniqueID string, pageSize int64, cursor string, opts Options,
+) ([]map[string]any, string, error) {
+	config := &adapter.Config{}
+	if opts.Config != nil {
+		*config = *opts.Config
+	}
+
+	recorder := &pageRecorder{Client: client}
+	a := &adapter.Adapter{Client: recorder, Logger: logging.Discard()}
+
+	response := a.GetPage(ctx, &framework.Request[adapter.Config]{
+		Address: opts.BaseURL,
+		Auth:    &framework.DatasourceAuthCredentials{HTTPAuthorization: opts.Token},
+		Config:  config,
+		Entity: framework.EntityConfig{
+			ExternalId: entityExternalID,
+			Attributes: []*framework.AttributeConfig{{
+				ExternalId: uniqueID,
+				Type:       framework.AttributeTypeString,
+			}},
+		},
+		Ordered:  opts.Ordered,
+		PageSize: pageSize,
+		Cursor:   cursor,
+	})
+
+	if response.Error != nil {
+		return nil, "", fmt.Errorf("%s: %s", response.Error.Code, response.Error.Message)
+	}
+
+	return recorder.objects, response.Success.NextCursor, nil
+}
+
+// pageRecorder is a Client that keeps the objects of the last page returned by its client.
+type pageRecorder struct {
+	adapter.Client
+
+	objects []map[string]any
+}
+
+func (r *pageRecorder) GetPage(ctx context.Context, request *adapter.Request) (*adapter.Response, *framework.Error) {
+	response, err := r.Client.GetPage(ctx, request)
+	if response != nil {
+		r.objects = response.Objects
+	}
+
+	return response, err
+}
+
+// uniqueIDs returns the unique ID of every object, or an empty string if an object has none.
+func uniqueIDs(objects []map[string]any, uniqueID string) []string {
+	ids := make([]string, 0, len(objects))
+
+	for _, object := range objects {
+		id, _ := object[uniqueID].(string)
+		ids = append(ids, id)
+	}
+
+	return ids
+}
+
+// sameIDs returns true if both pages have the same objects, in any order.
+func sameIDs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	a, b = append([]string(nil), a...), append([]string(nil), b...)
+	sort.Strings(a)
+	sort.Strings(b)
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (o Options) withDefaults() Options {
+	if len(o.Entities) == 0 {
+		for entityExternalID := range adapter.ValidEntityExternalIDs {
+			o.Entities = append(o.Entities, entityExternalID)
+		}
+
+		sort.Strings(o.Entities)
+	}
+
+	if len(o.PageSizes) == 0 {
+		o.PageSizes = DefaultPageSizes
+	}
+
+	if o.MaxPages <= 0 {
+		o.MaxPages = DefaultMaxPages
+	}
+
+	return o
+}
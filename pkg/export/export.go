@@ -0,0 +1,372 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package export exports every object of the adapter's entities to files, one per entity, as
+// NDJSON or CSV. Pages are requested with Adapter.RequestPageFromDatasource, as for SGNL, and
+// the export is checkpointed after every page so that it can be resumed from the last cursor.
+// A manifest of the exported files and their object counts is written when every entity has
+// been exported.
+//
+// An export directory contains:
+//
+//	users.ndjson    The objects of the users entity, one JSON object per line.
+//	state.json      The checkpoint of the export: the cursor, object count and file size of each entity.
+//	manifest.json   The exported files, with their object counts and SHA-256 digests.
+package export
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/adapter"
+)
+
+// Format is the format of the exported files.
+type Format string
+
+const (
+	// FormatNDJSON exports each object as a JSON object on its own line.
+	FormatNDJSON Format = "ndjson"
+
+	// FormatCSV exports each object as a CSV record, with a column per attribute. List values are
+	// JSON arrays.
+	FormatCSV Format = "csv"
+)
+
+const (
+	// StateFile is the name of the checkpoint file in the export directory.
+	StateFile = "state.json"
+
+	// ManifestFile is the name of the manifest file in the export directory.
+	ManifestFile = "manifest.json"
+)
+
+// ErrExportExists is returned when the export directory contains a previous export, which is
+// only continued if Options.Resume is set.
+var ErrExportExists = errors.New("export directory contains a previous export")
+
+// Entity is an entity to export.
+type Entity struct {
+	// ExternalID is the external ID of the entity.
+	ExternalID string
+
+	// Attributes are the exported attributes, including the unique ID attribute.
+	Attributes []*framework.AttributeConfig
+}
+
+// Options configure an export.
+type Options struct {
+	// Dir is the export directory. It is created if needed.
+	Dir string
+
+	// Format is the format of the exported files.
+	// Defaults to FormatNDJSON.
+	Format Format
+
+	// Address is the address of the datasource.
+	Address string
+
+	// Auth are the credentials of the datasource.
+	Auth *framework.DatasourceAuthCredentials
+
+	// Config is the adapter config of the requests.
+	// Optional.
+	Config *adapter.Config
+
+	// PageSize is the size of the requested pages.
+	// Defaults to the maximum page size of each entity.
+	PageSize int64
+
+	// Resume continues the export in Dir, if any, from the last checkpoint.
+	Resume bool
+
+	// Progress is called after every exported page.
+	// Optional.
+	Progress func(Progress)
+}
+
+// Progress is the progress of the export of an entity.
+type Progress struct {
+	Entity  string
+	Pages   int
+	Objects int
+
+	// Done is true once the last page of the entity has been exported.
+	Done bool
+
+	// Elapsed is the duration of the export of the entity in this run, which excludes the pages
+	// exported before the export was resumed.
+	Elapsed time.Duration
+}
+
+// Manifest describes a completed export.
+type Manifest struct {
+	Address     string           `json:"address"`
+	Format      Format           `json:"format"`
+	StartedAt   time.Time        `json:"startedAt"`
+	CompletedAt time.Time        `json:"completedAt"`
+	Entities    []EntityManifest `json:"entities"`
+}
+
+// EntityManifest describes the exported file of an entity.
+type EntityManifest struct {
+	Entity  string `json:"entity"`
+	File    string `json:"file"`
+	Objects int    `json:"objects"`
+	Pages   int    `json:"pages"`
+	SHA256  string `json:"sha256"`
+}
+
+// Export exports every object of the entities with the adapter, and returns the manifest of the
+// export, also written to the export directory.
+// If a page fails, the export stops and the error is returned. The export can then be resumed
+// with Options.Resume.
+func Export(ctx context.Context, a *adapter.Adapter, entities []Entity, opts Options) (*Manifest, error) {
+	if opts.Format == "" {
+		opts.Format = FormatNDJSON
+	}
+
+	if opts.Format != FormatNDJSON && opts.Format != FormatCSV {
+		return nil, fmt.Errorf("unknown format %q", opts.Format)
+	}
+
+	if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create export directory: %w", err)
+	}
+
+	st, err := loadState(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entity := range entities {
+		checkpoint := st.entity(entity)
+
+		if !equal(checkpoint.Columns, columns(entity)) {
+			return nil, fmt.Errorf("the attributes of %s differ from those of the export being resumed", entity.ExternalID)
+		}
+
+		if checkpoint.Done {
+			continue
+		}
+
+		if err := exportEntity(ctx, a, entity, checkpoint, st, opts); err != nil {
+			return nil, fmt.Errorf("failed to export %s: %w", entity.ExternalID, err)
+		}
+	}
+
+	manifest := &Manifest{
+		Address:     opts.Address,
+		Format:      opts.Format,
+		StartedAt:   st.StartedAt,
+		CompletedAt: time.Now().UTC(),
+	}
+
+	for _, entity := range entities {
+		checkpoint := st.Entities[entity.ExternalID]
+		file := fileName(entity, opts.Format)
+
+		digest, err := fileDigest(filepath.Join(opts.Dir, file))
+		if err != nil {
+			return nil, err
+		}
+
+		manifest.Entities = append(manifest.Entities, EntityManifest{
+			Entity:  entity.ExternalID,
+			File:    file,
+			Objects: checkpoint.Objects,
+			Pages:   checkpoint.Pages,
+			SHA256:  digest,
+		})
+	}
+
+	if err := writeJSON(filepath.Join(opts.Dir, ManifestFile), manifest); err != nil {
+		return nil, fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	return manifest, nil
+}
+
+// exportEntity exports the pages of the entity from its last checkpoint.
+func exportEntity(ctx context.Context, a *adapter.Adapter, entity Entity, checkpoint *entityState, st *state, opts Options) error {
+	f, err := os.OpenFile(filepath.Join(opts.Dir, fileName(entity, opts.Format)), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	// Objects written after the last checkpoint are requested again, so they are discarded.
+	if err := f.Truncate(checkpoint.Size); err != nil {
+		return err
+	}
+
+	if _, err := f.Seek(checkpoint.Size, io.SeekStart); err != nil {
+		return err
+	}
+
+	w := newWriter(opts.Format, f, columns(entity))
+
+	if checkpoint.Size == 0 {
+		if err := w.Begin(); err != nil {
+			return err
+		}
+	}
+
+	config := &adapter.Config{}
+	if opts.Config != nil {
+		*config = *opts.Config
+	}
+
+	pageSize := opts.PageSize
+	if pageSize <= 0 {
+		pageSize = adapter.ValidEntityExternalIDs[entity.ExternalID].MaxPageSize()
+	}
+
+	request := &framework.Request[adapter.Config]{
+		Address:  opts.Address,
+		Auth:     opts.Auth,
+		Config:   config,
+		Entity:   framework.EntityConfig{ExternalId: entity.ExternalID, Attributes: entity.Attributes},
+		PageSize: pageSize,
+		Cursor:   checkpoint.Cursor,
+	}
+
+	if adapterErr := a.ValidateGetPageRequest(ctx, request); adapterErr != nil {
+		return fmt.Errorf("%s: %s", adapterErr.Code, adapterErr.Message)
+	}
+
+	start := time.Now()
+
+	for {
+		response := a.RequestPageFromDatasource(ctx, request)
+		if response.Error != nil {
+			return fmt.Errorf("page %d: %s: %s", checkpoint.Pages, response.Error.Code, response.Error.Message)
+		}
+
+		for _, object := range response.Success.Objects {
+			if err := w.Write(object); err != nil {
+				return err
+			}
+		}
+
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		// The page must be durably written before the checkpoint moves past it.
+		if err := f.Sync(); err != nil {
+			return err
+		}
+
+		size, err := f.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return err
+		}
+
+		checkpoint.Pages++
+		checkpoint.Objects += len(response.Success.Objects)
+		checkpoint.Cursor = response.Success.NextCursor
+		checkpoint.Size = size
+		checkpoint.Done = response.Success.NextCursor == ""
+
+		if err := st.save(opts.Dir); err != nil {
+			return fmt.Errorf("failed to save checkpoint: %w", err)
+		}
+
+		if opts.Progress != nil {
+			opts.Progress(Progress{
+				Entity:  entity.ExternalID,
+				Pages:   checkpoint.Pages,
+				Objects: checkpoint.Objects,
+				Done:    checkpoint.Done,
+				Elapsed: time.Since(start),
+			})
+		}
+
+		if checkpoint.Done {
+			return nil
+		}
+
+		request.Cursor = response.Success.NextCursor
+	}
+}
+
+func fileName(entity Entity, format Format) string {
+	return entity.ExternalID + "." + string(format)
+}
+
+// columns returns the external IDs of the attributes of the entity.
+func columns(entity Entity) []string {
+	columns := make([]string, 0, len(entity.Attributes))
+	for _, attribute := range entity.Attributes {
+		columns = append(columns, attribute.ExternalId)
+	}
+
+	return columns
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func fileDigest(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// writeJSON writes v as indented JSON to the file at path, replacing it atomically.
+func writeJSON(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
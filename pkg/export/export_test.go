@@ -0,0 +1,259 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/adapter"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/adaptertest"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/export"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/logging"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/pagerdutymock"
+)
+
+// newExport returns the adapter and options of an export of the fake API to a new directory.
+func newExport(t *testing.T) (*pagerdutymock.TestServer, *adapter.Adapter, export.Options) {
+	t.Helper()
+
+	mock, client := adaptertest.NewMockClient(t, nil)
+
+	return mock, &adapter.Adapter{Client: client, Logger: logging.Discard()}, export.Options{
+		Dir:      t.TempDir(),
+		Address:  mock.URL,
+		Auth:     &framework.DatasourceAuthCredentials{HTTPAuthorization: "Token token=test"},
+		PageSize: 25,
+	}
+}
+
+func entity(externalID string, attributes ...string) export.Entity {
+	e := export.Entity{ExternalID: externalID}
+
+	for _, attribute := range attributes {
+		attributeType := framework.AttributeTypeString
+		if attribute == "invitation_sent" {
+			attributeType = framework.AttributeTypeBool
+		}
+
+		e.Attributes = append(e.Attributes, &framework.AttributeConfig{ExternalId: attribute, Type: attributeType})
+	}
+
+	return e
+}
+
+// fixtureIDs returns the sorted IDs of the fixtures of the collection.
+func fixtureIDs(fixtures pagerdutymock.Fixtures, collection string) []string {
+	var ids []string
+	for _, object := range fixtures[collection] {
+		ids = append(ids, object["id"].(string))
+	}
+
+	sort.Strings(ids)
+
+	return ids
+}
+
+// exportedIDs returns the sorted IDs of the objects of an NDJSON file.
+func exportedIDs(t *testing.T, path string) []string {
+	t.Helper()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer f.Close()
+
+	var ids []string
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var object map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &object); err != nil {
+			t.Fatalf("Line %d of %s is not a JSON object: %v", len(ids)+1, path, err)
+		}
+
+		ids = append(ids, object["id"].(string))
+	}
+
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Strings(ids)
+
+	return ids
+}
+
+func TestExport(t *testing.T) {
+	mock, a, opts := newExport(t)
+
+	manifest, err := export.Export(context.Background(), a, []export.Entity{entity("users", "id", "email"), entity("teams", "id")}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, collection := range []string{"users", "teams"} {
+		want := fixtureIDs(mock.Fixtures, collection)
+
+		if got := exportedIDs(t, filepath.Join(opts.Dir, collection+".ndjson")); strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("Got %s %v, want %v", collection, got, want)
+		}
+	}
+
+	var written export.Manifest
+
+	data, err := os.ReadFile(filepath.Join(opts.Dir, export.ManifestFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := json.Unmarshal(data, &written); err != nil {
+		t.Fatal(err)
+	}
+
+	users := written.Entities[0]
+	if users.Entity != "users" || users.Objects != len(mock.Fixtures["users"]) || users.Pages != 3 || users.SHA256 != manifest.Entities[0].SHA256 {
+		t.Errorf("Got users manifest %+v, want %d objects in 3 pages", users, len(mock.Fixtures["users"]))
+	}
+
+	// An export is only resumed on request.
+	if _, err := export.Export(context.Background(), a, []export.Entity{entity("users", "id", "email")}, opts); !errors.Is(err, export.ErrExportExists) {
+		t.Errorf("Got error %v exporting to the same directory, want %v", err, export.ErrExportExists)
+	}
+}
+
+// TestExportResume verifies that a failed export resumes from the cursor of its last checkpoint,
+// discarding the objects written after it.
+func TestExportResume(t *testing.T) {
+	mock, a, opts := newExport(t)
+
+	users := entity("users", "id", "email")
+	path := filepath.Join(opts.Dir, "users.ndjson")
+
+	// The second page fails.
+	opts.Progress = func(progress export.Progress) {
+		if progress.Pages == 1 {
+			mock.AddFault(pagerdutymock.Fault{Path: "users", Status: http.StatusInternalServerError, Times: 1})
+		}
+	}
+
+	if _, err := export.Export(context.Background(), a, []export.Entity{users}, opts); err == nil {
+		t.Fatal("Got no error for the failed page")
+	}
+
+	if got := len(exportedIDs(t, path)); got != 25 {
+		t.Fatalf("Got %d users exported before the failure, want the 25 of the first page", got)
+	}
+
+	// Objects written after the checkpoint, e.g. by an export killed while writing a page, are
+	// discarded when the export is resumed.
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := f.WriteString(`{"id": "PARTIAL"}` + "\n" + `{"id": "PAR`); err != nil {
+		t.Fatal(err)
+	}
+
+	f.Close()
+
+	opts.Progress = nil
+	opts.Resume = true
+	requests := len(mock.Requests())
+
+	manifest, err := export.Export(context.Background(), a, []export.Entity{users}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first page is not requested again.
+	if offset := mock.Requests()[requests].URL.Query().Get("offset"); offset != "25" {
+		t.Errorf("Got the resumed export starting at offset %q, want 25", offset)
+	}
+
+	want := fixtureIDs(mock.Fixtures, "users")
+	if got := exportedIDs(t, path); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Got users %v, want %v", got, want)
+	}
+
+	if got := manifest.Entities[0]; got.Objects != len(want) || got.Pages != 3 {
+		t.Errorf("Got manifest %+v, want %d objects in 3 pages", got, len(want))
+	}
+
+	// The attributes of a resumed export can't change.
+	if _, err := export.Export(context.Background(), a, []export.Entity{entity("users", "id")}, opts); err == nil {
+		t.Error("Got no error resuming the export with other attributes")
+	}
+}
+
+func TestExportCSV(t *testing.T) {
+	mock, a, opts := newExport(t)
+	opts.Format = export.FormatCSV
+
+	if _, err := export.Export(context.Background(), a, []export.Entity{entity("users", "id", "email", "invitation_sent")}, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(filepath.Join(opts.Dir, "users.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][]string{{"id", "email", "invitation_sent"}}
+	for _, user := range mock.Fixtures["users"] {
+		want = append(want, []string{user["id"].(string), user["email"].(string), strconv.FormatBool(user["invitation_sent"].(bool))})
+	}
+
+	// Records are compared regardless of their order.
+	sort.Slice(want[1:], func(i, j int) bool { return want[1+i][0] < want[1+j][0] })
+
+	if len(records) > 0 {
+		sort.Slice(records[1:], func(i, j int) bool { return records[1+i][0] < records[1+j][0] })
+	}
+
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("Got CSV %v, want %v", records, want)
+	}
+
+	// A CSV export can't be resumed as NDJSON.
+	opts.Format = export.FormatNDJSON
+	opts.Resume = true
+
+	if _, err := export.Export(context.Background(), a, []export.Entity{entity("users", "id", "email", "invitation_sent")}, opts); err == nil {
+		t.Error("Got no error resuming a CSV export as NDJSON")
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// state is the checkpoint of an export, saved after every page.
+type state struct {
+	Format    Format                  `json:"format"`
+	StartedAt time.Time               `json:"startedAt"`
+	Entities  map[string]*entityState `json:"entities"`
+}
+
+// entityState is the checkpoint of the export of an entity.
+type entityState struct {
+	// Cursor is the cursor of the next page, or empty if no page has been exported yet or the
+	// last page has been exported.
+	Cursor string `json:"cursor,omitempty"`
+
+	Objects int `json:"objects"`
+	Pages   int `json:"pages"`
+
+	// Size is the size of the exported file once the last page was written. The file is truncated
+	// to this size when the export is resumed.
+	Size int64 `json:"size"`
+
+	Done bool `json:"done"`
+
+	// Columns are the external IDs of the exported attributes.
+	Columns []string `json:"columns"`
+}
+
+// loadState returns the checkpoint of the export in the export directory, or a new checkpoint if
+// there is no export to resume.
+func loadState(opts Options) (*state, error) {
+	data, err := os.ReadFile(filepath.Join(opts.Dir, StateFile))
+
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return &state{
+			Format:    opts.Format,
+			StartedAt: time.Now().UTC(),
+			Entities:  make(map[string]*entityState),
+		}, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	case !opts.Resume:
+		return nil, fmt.Errorf("%w in %s, resume it or choose another directory", ErrExportExists, opts.Dir)
+	}
+
+	var st state
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint: %w", err)
+	}
+
+	if st.Format != opts.Format {
+		return nil, fmt.Errorf("the export being resumed is in %s, not %s", st.Format, opts.Format)
+	}
+
+	if st.Entities == nil {
+		st.Entities = make(map[string]*entityState)
+	}
+
+	return &st, nil
+}
+
+// entity returns the checkpoint of the entity, which is added if it wasn't exported yet.
+func (s *state) entity(entity Entity) *entityState {
+	checkpoint, found := s.Entities[entity.ExternalID]
+	if !found {
+		checkpoint = &entityState{Columns: columns(entity)}
+		s.Entities[entity.ExternalID] = checkpoint
+	}
+
+	return checkpoint
+}
+
+func (s *state) save(dir string) error {
+	return writeJSON(filepath.Join(dir, StateFile), s)
+}
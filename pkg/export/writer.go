@@ -0,0 +1,122 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+)
+
+// writer writes the exported objects of an entity to its file.
+type writer interface {
+	// Begin is called before the first object of a new file.
+	Begin() error
+
+	Write(object framework.Object) error
+
+	// Flush writes the buffered objects to the file.
+	Flush() error
+}
+
+func newWriter(format Format, w io.Writer, columns []string) writer {
+	if format == FormatCSV {
+		return &csvWriter{w: csv.NewWriter(w), columns: columns}
+	}
+
+	buf := bufio.NewWriter(w)
+
+	return &ndjsonWriter{buf: buf, encoder: json.NewEncoder(buf)}
+}
+
+// ndjsonWriter writes each object as a JSON object on its own line. Datetimes are RFC 3339
+// strings.
+type ndjsonWriter struct {
+	buf     *bufio.Writer
+	encoder *json.Encoder
+}
+
+func (w *ndjsonWriter) Begin() error {
+	return nil
+}
+
+func (w *ndjsonWriter) Write(object framework.Object) error {
+	return w.encoder.Encode(object)
+}
+
+func (w *ndjsonWriter) Flush() error {
+	return w.buf.Flush()
+}
+
+// csvWriter writes a header of the attributes' external IDs, then each object as a record with a
+// column per attribute.
+type csvWriter struct {
+	w       *csv.Writer
+	columns []string
+	record  []string
+}
+
+func (w *csvWriter) Begin() error {
+	return w.w.Write(w.columns)
+}
+
+func (w *csvWriter) Write(object framework.Object) error {
+	w.record = w.record[:0]
+
+	for _, column := range w.columns {
+		value, err := cell(object[column])
+		if err != nil {
+			return err
+		}
+
+		w.record = append(w.record, value)
+	}
+
+	return w.w.Write(w.record)
+}
+
+func (w *csvWriter) Flush() error {
+	w.w.Flush()
+
+	return w.w.Error()
+}
+
+// cell returns the CSV cell of an attribute value. Lists and durations are JSON, and datetimes
+// are RFC 3339 strings.
+func cell(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	default:
+		data, err := json.Marshal(v)
+
+		return string(data), err
+	}
+}
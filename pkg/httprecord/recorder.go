@@ -0,0 +1,368 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package httprecord records the requests of the Datasource's client to PagerDuty and their
+// responses to fixture files, called cassettes, and replays them without network access, to build
+// regression tests from real-world responses.
+//
+// Secrets and personal data are scrubbed before recording, see Sanitizer. Responses are returned
+// sanitized in both modes, for tests to behave the same whether they record or replay.
+//
+//	func TestUsers(t *testing.T) {
+//		recorder := httprecord.NewForTest(t, "testdata/users.json")
+//
+//		h := adaptertest.New(t, adaptertest.Options{
+//			ClientOptions: []adapter.ClientOption{adapter.WithTransport(recorder)},
+//		})
+//		...
+//	}
+//
+// Tests replay their cassette by default, and record it against PagerDuty when the
+// HTTPRECORD_MODE environment variable is "record".
+package httprecord
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// ModeEnv is the environment variable that sets the mode of the recorders created by NewForTest.
+const ModeEnv = "HTTPRECORD_MODE"
+
+// Mode is the mode of a Recorder.
+type Mode int
+
+const (
+	// ModeReplay replays the responses of the cassette, without network access. Requests that were
+	// not recorded fail.
+	ModeReplay Mode = iota
+
+	// ModeRecord sends requests to the datasource and records their responses, replacing the
+	// cassette when saved.
+	ModeRecord
+
+	// ModeAuto replays the cassette if it exists, and records it otherwise.
+	ModeAuto
+)
+
+// ParseMode returns the mode named "replay", "record" or "auto".
+func ParseMode(name string) (Mode, error) {
+	switch strings.ToLower(name) {
+	case "replay":
+		return ModeReplay, nil
+	case "record":
+		return ModeRecord, nil
+	case "auto":
+		return ModeAuto, nil
+	default:
+		return ModeReplay, fmt.Errorf("unknown mode %q, must be replay, record or auto", name)
+	}
+}
+
+func (m Mode) String() string {
+	switch m {
+	case ModeRecord:
+		return "record"
+	case ModeAuto:
+		return "auto"
+	default:
+		return "replay"
+	}
+}
+
+// Cassette is the content of a fixture file: the recorded interactions, in the order they were
+// sent.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a recorded request and its response.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request identifies a recorded request. Its headers are not recorded, since they contain the
+// datasource's credentials.
+type Request struct {
+	Method string `json:"method"`
+
+	// URL is the sanitized path and query of the request, see Sanitizer.URL. The host is not
+	// recorded, for the cassette to be replayed against any datasource address.
+	URL string `json:"url"`
+}
+
+// Response is a recorded response.
+type Response struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+
+	// Body is the sanitized body, if it is JSON.
+	Body json.RawMessage `json:"body,omitempty"`
+
+	// BodyText is the sanitized body, if it is not JSON.
+	BodyText string `json:"body_text,omitempty"`
+}
+
+// Recorder is an http.RoundTripper that records or replays a cassette.
+// Set it as the transport of the Datasource's client with adapter.WithTransport.
+type Recorder struct {
+	// Sanitizer scrubs the recorded requests and responses.
+	// Defaults to DefaultSanitizer().
+	Sanitizer *Sanitizer
+
+	// Transport sends the requests to record.
+	// Defaults to http.DefaultTransport.
+	Transport http.RoundTripper
+
+	path string
+	mode Mode
+
+	mu       sync.Mutex
+	cassette Cassette
+
+	// used are the replayed interactions, which are not replayed again.
+	used []bool
+}
+
+// New returns a recorder of the cassette at path. In ModeReplay, the cassette is loaded and must
+// exist. In ModeAuto, it is replayed if it exists, and recorded otherwise.
+func New(path string, mode Mode) (*Recorder, error) {
+	r := &Recorder{path: path, mode: mode}
+
+	if mode == ModeRecord {
+		return r, nil
+	}
+
+	data, err := os.ReadFile(path)
+
+	switch {
+	case mode == ModeAuto && errors.Is(err, os.ErrNotExist):
+		r.mode = ModeRecord
+
+		return r, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+
+	r.mode = ModeReplay
+
+	if err := json.Unmarshal(data, &r.cassette); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette %s: %w", path, err)
+	}
+
+	r.used = make([]bool, len(r.cassette.Interactions))
+
+	return r, nil
+}
+
+// NewForTest returns a recorder of the cassette at path, in the mode set by the ModeEnv
+// environment variable, replay by default. The recorded cassette is saved when the test completes,
+// unless it failed.
+func NewForTest(t testing.TB, path string) *Recorder {
+	t.Helper()
+
+	mode := ModeReplay
+
+	if name := os.Getenv(ModeEnv); name != "" {
+		var err error
+		if mode, err = ParseMode(name); err != nil {
+			t.Fatalf("Invalid %s: %v", ModeEnv, err)
+		}
+	}
+
+	r, err := New(path, mode)
+	if err != nil {
+		t.Fatalf("Failed to create recorder: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if t.Failed() {
+			return
+		}
+
+		if err := r.Save(); err != nil {
+			t.Errorf("Failed to save cassette: %v", err)
+		}
+	})
+
+	return r
+}
+
+// Mode returns whether the recorder records or replays. It is never ModeAuto.
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// Interactions returns the recorded interactions, or the interactions of the replayed cassette.
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]Interaction(nil), r.cassette.Interactions...)
+}
+
+// Unused returns the interactions of the replayed cassette that were not replayed, e.g. to check
+// that the adapter sent every recorded request.
+func (r *Recorder) Unused() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var unused []Interaction
+
+	for i, used := range r.used {
+		if !used {
+			unused = append(unused, r.cassette.Interactions[i])
+		}
+	}
+
+	return unused
+}
+
+// RoundTrip records or replays the response to the request. Requests are replayed if their method
+// and sanitized URL match those of a recorded request, see Sanitizer.URL.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorded := Request{Method: req.Method, URL: r.sanitizer().URL(req.URL)}
+
+	if r.mode == ModeRecord {
+		return r.record(req, recorded)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Identical requests, e.g. retries, are replayed in the order they were recorded.
+	for i, interaction := range r.cassette.Interactions {
+		if !r.used[i] && interaction.Request == recorded {
+			r.used[i] = true
+
+			return interaction.Response.httpResponse(req), nil
+		}
+	}
+
+	return nil, fmt.Errorf("no recorded response to %s %s in cassette %s", recorded.Method, recorded.URL, r.path)
+}
+
+func (r *Recorder) record(req *http.Request, recorded Request) (*http.Response, error) {
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	res, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response to record: %w", err)
+	}
+
+	sanitizer := r.sanitizer()
+
+	response := Response{
+		Status: res.StatusCode,
+		Header: sanitizer.Header(res.Header),
+	}
+
+	if body = sanitizer.Body(body); json.Valid(body) {
+		response.Body = body
+	} else {
+		response.BodyText = string(body)
+	}
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{Request: recorded, Response: response})
+	r.mu.Unlock()
+
+	return response.httpResponse(req), nil
+}
+
+// Save writes the recorded cassette to its path, creating its directory if needed. It does nothing
+// when replaying.
+func (r *Recorder) Save() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+
+	// URLs are kept readable, without escaping their & as \u0026.
+	var data bytes.Buffer
+
+	encoder := json.NewEncoder(&data)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+
+	r.mu.Lock()
+	err := encoder.Encode(r.cassette)
+	r.mu.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("failed to marshal cassette: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cassette directory: %w", err)
+	}
+
+	if err := os.WriteFile(r.path, data.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Recorder) sanitizer() *Sanitizer {
+	if r.Sanitizer == nil {
+		return DefaultSanitizer()
+	}
+
+	return r.Sanitizer
+}
+
+// httpResponse returns the recorded response to the request.
+func (r Response) httpResponse(req *http.Request) *http.Response {
+	body := []byte(r.BodyText)
+	if len(r.Body) > 0 {
+		body = r.Body
+	}
+
+	header := r.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.Status, http.StatusText(r.Status)),
+		StatusCode:    r.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,264 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httprecord_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/adapter"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/httprecord"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/logging"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/pagerdutymock"
+)
+
+func TestSanitizerURL(t *testing.T) {
+	tests := map[string]struct {
+		url  string
+		want string
+	}{
+		"no query": {
+			url:  "https://api.pagerduty.com/users",
+			want: "/users",
+		},
+		"sorted query": {
+			url:  "https://api.pagerduty.com/users?offset=25&limit=25&include%5B%5D=contact_methods",
+			want: "/users?include%5B%5D=contact_methods&limit=25&offset=25",
+		},
+		"secret parameter": {
+			url:  "https://api.pagerduty.com/users?api_token=s3cr3t",
+			want: "/users?api_token=%5BREDACTED%5D",
+		},
+		"email": {
+			url:  "https://api.pagerduty.com/users?query=jane.doe%40acme.com",
+			want: "/users?query=user-" + emailHash(t, "jane.doe@acme.com") + "%40example.com",
+		},
+		"volatile parameters": {
+			url:  "https://api.pagerduty.com/log_entries?since=2023-01-15T00%3A00%3A00Z&until=2024-03-01T12%3A34%3A56Z",
+			want: "/log_entries?since=%5BVOLATILE%5D&until=%5BVOLATILE%5D",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := httprecord.DefaultSanitizer().URL(u); got != tt.want {
+				t.Errorf("Got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordReplay(t *testing.T) {
+	const body = `{"users": [{"id": "PU1", "name": "Jane Doe", "email": "jane.doe@acme.com", "api_token": "s3cr3t",` +
+		` "html_url": "https://acme.pagerduty.com/users/PU1", "role": "admin"}]}`
+
+	datasource := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=s3cr3t")
+		_, _ = io.WriteString(w, body)
+	}))
+	defer datasource.Close()
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	recorder, err := httprecord.New(path, httprecord.ModeRecord)
+	if err != nil {
+		t.Fatalf("Failed to create recorder: %v", err)
+	}
+
+	recorder.Transport = datasource.Client().Transport
+
+	recorded := get(t, recorder, datasource.URL+"/users?token=t0k3n&until=2024-03-01T12%3A34%3A56Z")
+
+	if err := recorder.Save(); err != nil {
+		t.Fatalf("Failed to save cassette: %v", err)
+	}
+
+	cassette, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read cassette: %v", err)
+	}
+
+	for _, leaked := range []string{"Jane Doe", "jane.doe@acme.com", "s3cr3t", "t0k3n", "acme.pagerduty.com", "Set-Cookie"} {
+		if strings.Contains(string(cassette), leaked) || strings.Contains(recorded, leaked) {
+			t.Errorf("Cassette or recorded response contains %q:\n%s", leaked, cassette)
+		}
+	}
+
+	if !strings.Contains(recorded, `"role":"admin"`) {
+		t.Errorf("Recorded response doesn't contain the attributes that are not personal data: %s", recorded)
+	}
+
+	replayer, err := httprecord.New(path, httprecord.ModeReplay)
+	if err != nil {
+		t.Fatalf("Failed to create replayer: %v", err)
+	}
+
+	// The request is replayed at another address, and until another time.
+	replayed := get(t, replayer, "https://api.pagerduty.com/users?until=2025-06-30T00%3A00%3A00Z&token=another")
+	if compact(t, replayed) != compact(t, recorded) {
+		t.Errorf("Got replayed response %s, want %s", replayed, recorded)
+	}
+
+	if unused := replayer.Unused(); len(unused) != 0 {
+		t.Errorf("Got unused interactions %v", unused)
+	}
+
+	// The recorded interaction was replayed, and requests without recording fail.
+	for _, u := range []string{"https://api.pagerduty.com/users?until=2025-06-30T00%3A00%3A00Z", "https://api.pagerduty.com/teams"} {
+		if _, err := (&http.Client{Transport: replayer}).Get(u); err == nil {
+			t.Errorf("Got a response to %s, want an error", u)
+		}
+	}
+}
+
+// TestReplayIncrementalSync replays testdata/log_entries_since.json, an incremental sync of log entries
+// recorded from the fake API of package pagerdutymock. Its first page is requested until the
+// current time, which differs from the recorded until.
+// Run with HTTPRECORD_MODE=record to record the cassette again.
+func TestReplayIncrementalSync(t *testing.T) {
+	const since = "2023-01-15T00:00:00Z"
+
+	recorder := httprecord.NewForTest(t, "testdata/log_entries_since.json")
+
+	fixtures := pagerdutymock.DefaultFixtures(1)
+
+	baseURL := "https://api.pagerduty.com"
+
+	if recorder.Mode() == httprecord.ModeRecord {
+		mock := pagerdutymock.NewTestServer(t, fixtures)
+
+		recorder.Transport = mock.Client.Transport
+		baseURL = mock.URL
+	}
+
+	a := &adapter.Adapter{
+		Client: adapter.NewClient(10, nil, adapter.WithTransport(recorder)),
+		Logger: logging.Discard(),
+	}
+
+	request := &framework.Request[adapter.Config]{
+		Address: baseURL,
+		Auth:    &framework.DatasourceAuthCredentials{HTTPAuthorization: "Token token=test"},
+		Config:  &adapter.Config{Since: since},
+		Entity: framework.EntityConfig{
+			ExternalId: "log_entries",
+			Attributes: []*framework.AttributeConfig{{ExternalId: "id", Type: framework.AttributeTypeString}},
+		},
+		PageSize: 10,
+	}
+
+	var got []string
+
+	for page := 0; ; page++ {
+		if page == 100 {
+			t.Fatal("Cursors don't terminate after 100 pages")
+		}
+
+		response := a.GetPage(context.Background(), request)
+		if response.Error != nil {
+			t.Fatalf("Page %d: %s: %s", page, response.Error.Code, response.Error.Message)
+		}
+
+		for _, object := range response.Success.Objects {
+			got = append(got, object["id"].(string))
+		}
+
+		if response.Success.NextCursor == "" {
+			break
+		}
+
+		request.Cursor = response.Success.NextCursor
+	}
+
+	var want []string
+
+	watermark, _ := time.Parse(time.RFC3339, since)
+
+	for _, entry := range fixtures["log_entries"] {
+		if created, _ := time.Parse(time.RFC3339, entry["created_at"].(string)); !created.Before(watermark) {
+			want = append(want, entry["id"].(string))
+		}
+	}
+
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Got log entries %v, want %v", got, want)
+	}
+
+	if unused := recorder.Unused(); len(unused) != 0 {
+		t.Errorf("Got %d unused interactions, want none", len(unused))
+	}
+}
+
+// get sends a GET request through the transport, and returns the body of the response.
+func get(t *testing.T, transport http.RoundTripper, u string) string {
+	t.Helper()
+
+	res, err := (&http.Client{Transport: transport}).Get(u)
+	if err != nil {
+		t.Fatalf("Failed to get %s: %v", u, err)
+	}
+
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatalf("Failed to read response to %s: %v", u, err)
+	}
+
+	return string(body)
+}
+
+// compact returns the JSON body without insignificant spaces.
+func compact(t *testing.T, body string) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, []byte(body)); err != nil {
+		t.Fatalf("Invalid JSON body %s: %v", body, err)
+	}
+
+	return buf.String()
+}
+
+// emailHash returns the hash of the pseudonym of an email address.
+func emailHash(t *testing.T, email string) string {
+	t.Helper()
+
+	pseudonym := httprecord.DefaultSanitizer().String(email)
+
+	hash, found := strings.CutPrefix(pseudonym, "user-")
+	if !found {
+		t.Fatalf("Got pseudonym %q of %q, want user-<hash>@example.com", pseudonym, email)
+	}
+
+	return strings.TrimSuffix(hash, "@example.com")
+}
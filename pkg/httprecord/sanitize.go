@@ -0,0 +1,218 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httprecord
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/tksarunachalam/sgnl-adapter/pkg/logging"
+)
+
+// DefaultPIIKeys are the keys of the JSON attributes whose string values are personal data, which
+// are replaced by pseudonyms in recorded bodies.
+var DefaultPIIKeys = []string{
+	"name", "summary", "email", "address", "phone_number", "label", "description", "job_title",
+	"avatar_url", "subject", "to", "from", "point_of_contact", "remote_address", "user_agent", "title",
+}
+
+// DefaultHeaders are the response headers kept in recordings. Other headers are dropped.
+var DefaultHeaders = []string{"Content-Type", "Retry-After", "Ratelimit-Limit", "Ratelimit-Remaining", "Ratelimit-Reset"}
+
+// DefaultVolatileParams are the query parameters whose values change from one run to the next,
+// e.g. the until of incremental syncs, which is the time of their first page, or a since resolved
+// from a lookback duration.
+var DefaultVolatileParams = []string{"since", "until"}
+
+// Volatile replaces the values of volatile query parameters in recorded requests.
+const Volatile = "[VOLATILE]"
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+
+	// subdomainPattern matches the URLs of PagerDuty accounts, e.g. https://acme.pagerduty.com,
+	// and of the API, which are kept.
+	subdomainPattern = regexp.MustCompile(`https://[A-Za-z0-9\-]+\.pagerduty\.com`)
+)
+
+// Sanitizer scrubs secrets and personal data from recorded requests and responses.
+// Personal data is replaced by pseudonyms derived from a hash of the value, so that the same
+// value is always replaced by the same pseudonym, across responses and recordings.
+type Sanitizer struct {
+	// PIIKeys are the keys of the JSON attributes whose string values are replaced by pseudonyms.
+	PIIKeys []string
+
+	// Headers are the response headers kept in recordings.
+	Headers []string
+
+	// VolatileParams are the query parameters whose values are replaced by Volatile, for requests
+	// to match their recording regardless of these values.
+	VolatileParams []string
+}
+
+// DefaultSanitizer returns a Sanitizer that scrubs DefaultPIIKeys, keeps DefaultHeaders and
+// ignores the values of DefaultVolatileParams.
+func DefaultSanitizer() *Sanitizer {
+	return &Sanitizer{PIIKeys: DefaultPIIKeys, Headers: DefaultHeaders, VolatileParams: DefaultVolatileParams}
+}
+
+// pseudonym returns the pseudonym of a personal value of the attribute.
+func pseudonym(key, value string) string {
+	sum := sha256.Sum256([]byte(value))
+	hash := hex.EncodeToString(sum[:4])
+
+	if strings.Contains(value, "@") && emailPattern.FindString(value) == value {
+		return "user-" + hash + "@example.com"
+	}
+
+	return key + "-" + hash
+}
+
+// String scrubs the email addresses and PagerDuty account subdomains in a string.
+func (s *Sanitizer) String(value string) string {
+	value = emailPattern.ReplaceAllStringFunc(value, func(email string) string {
+		return pseudonym("email", email)
+	})
+
+	return subdomainPattern.ReplaceAllStringFunc(value, func(account string) string {
+		if account == "https://api.pagerduty.com" {
+			return account
+		}
+
+		return "https://example.pagerduty.com"
+	})
+}
+
+// Body returns the sanitized body. JSON bodies have their secret attributes redacted and their
+// personal attributes replaced by pseudonyms. Other bodies only have their email addresses
+// scrubbed.
+func (s *Sanitizer) Body(body []byte) []byte {
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return []byte(s.String(string(body)))
+	}
+
+	sanitized, err := json.Marshal(s.value("", value))
+	if err != nil {
+		return []byte(s.String(string(body)))
+	}
+
+	return sanitized
+}
+
+// value returns the sanitized JSON value of the attribute.
+func (s *Sanitizer) value(key string, value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for childKey, child := range v {
+			v[childKey] = s.value(childKey, child)
+		}
+
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = s.value(key, item)
+		}
+
+		return v
+	case string:
+		switch {
+		case v == "":
+			return v
+		case logging.IsSecretKey(key):
+			return logging.Redacted
+		case s.isPIIKey(key):
+			return pseudonym(key, v)
+		default:
+			return s.String(v)
+		}
+	default:
+		return v
+	}
+}
+
+func (s *Sanitizer) isPIIKey(key string) bool {
+	return contains(s.PIIKeys, key)
+}
+
+func (s *Sanitizer) isVolatileParam(key string) bool {
+	return contains(s.VolatileParams, key)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Header returns the headers to record among the response headers.
+func (s *Sanitizer) Header(header http.Header) http.Header {
+	kept := http.Header{}
+
+	for _, key := range s.Headers {
+		if values := header.Values(key); len(values) > 0 && !logging.IsSecretKey(key) {
+			kept[http.CanonicalHeaderKey(key)] = values
+		}
+	}
+
+	return kept
+}
+
+// URL returns the sanitized path and query of a request URL, which identifies the request in
+// recordings. Query parameters are sorted, and their values scrubbed, or replaced by Volatile for
+// VolatileParams.
+func (s *Sanitizer) URL(u *url.URL) string {
+	query := u.Query()
+
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	sanitized := url.Values{}
+
+	for _, key := range keys {
+		for _, value := range query[key] {
+			switch {
+			case logging.IsSecretKey(key):
+				value = logging.Redacted
+			case s.isVolatileParam(key):
+				value = Volatile
+			default:
+				value = s.String(value)
+			}
+
+			sanitized.Add(key, value)
+		}
+	}
+
+	if len(sanitized) == 0 {
+		return u.EscapedPath()
+	}
+
+	return u.EscapedPath() + "?" + sanitized.Encode()
+}
@@ -0,0 +1,128 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package logging provides the structured loggers of the adapter, which redact secrets, and
+// carries per-request loggers in contexts.
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// Redacted replaces the value of secret attributes.
+const Redacted = "[REDACTED]"
+
+// secretKeys are the substrings of the (case-insensitive) keys of attributes whose values are secrets.
+var secretKeys = []string{"authorization", "token", "password", "secret", "apikey", "api_key", "api-key"}
+
+// New returns a logger that writes to w at the given level, as JSON if format is "json" or as
+// text if it is "text", and redacts secrets.
+func New(w io.Writer, level slog.Level, format string) (*slog.Logger, error) {
+	opts := &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: Redact,
+	}
+
+	switch format {
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	case "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q, must be json or text", format)
+	}
+}
+
+// Discard returns a logger that drops every log.
+func Discard() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelError + 1}))
+}
+
+// Redact replaces the values of attributes whose key names a secret (e.g. "Authorization",
+// "token" or "password") and of the Authorization header of http.Header values.
+// It is meant to be used as the ReplaceAttr function of slog.HandlerOptions.
+func Redact(_ []string, attr slog.Attr) slog.Attr {
+	if IsSecretKey(attr.Key) {
+		return slog.String(attr.Key, Redacted)
+	}
+
+	if attr.Value.Kind() == slog.KindAny {
+		if header, ok := attr.Value.Any().(http.Header); ok {
+			return slog.Any(attr.Key, RedactHeader(header))
+		}
+	}
+
+	return attr
+}
+
+// IsSecretKey returns true if the key of an attribute or header names a secret.
+func IsSecretKey(key string) bool {
+	key = strings.ToLower(key)
+
+	for _, secretKey := range secretKeys {
+		if strings.Contains(key, secretKey) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RedactHeader returns a copy of the header whose secret values are redacted.
+func RedactHeader(header http.Header) http.Header {
+	redacted := header.Clone()
+
+	for key := range redacted {
+		if IsSecretKey(key) {
+			redacted[key] = []string{Redacted}
+		}
+	}
+
+	return redacted
+}
+
+type contextKey struct{}
+
+// WithLogger returns a copy of ctx that carries the logger.
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the logger carried by ctx, or fallback if ctx carries no logger.
+func FromContext(ctx context.Context, fallback *slog.Logger) *slog.Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+		return logger
+	}
+
+	return fallback
+}
+
+// NewRequestID returns a random ID to correlate the logs of a request.
+func NewRequestID() string {
+	id := make([]byte, 8)
+
+	if _, err := rand.Read(id); err != nil {
+		// crypto/rand never fails on supported platforms.
+		panic(err)
+	}
+
+	return hex.EncodeToString(id)
+}
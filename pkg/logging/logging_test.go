@@ -0,0 +1,157 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tksarunachalam/sgnl-adapter/pkg/logging"
+)
+
+const secret = "s3cr3t"
+
+func TestRedact(t *testing.T) {
+	header := http.Header{
+		"Authorization": {"Token token=" + secret},
+		"Token":         {secret},
+		"Accept":        {"application/json"},
+	}
+
+	tests := map[string]struct {
+		log func(logger *slog.Logger)
+
+		// want is the expected log record, without its time, level and message.
+		want map[string]any
+	}{
+		"secret_attributes": {
+			log: func(logger *slog.Logger) {
+				logger.Info("test", "Authorization", "Bearer "+secret, "api_token", secret, "password", secret,
+					"client_secret", secret, "apiKey", secret, "entity", "users")
+			},
+			want: map[string]any{
+				"Authorization": logging.Redacted,
+				"api_token":     logging.Redacted,
+				"password":      logging.Redacted,
+				"client_secret": logging.Redacted,
+				"apiKey":        logging.Redacted,
+				"entity":        "users",
+			},
+		},
+		"headers": {
+			log: func(logger *slog.Logger) {
+				logger.Info("test", slog.Any("headers", header))
+			},
+			want: map[string]any{
+				"headers": map[string]any{
+					"Authorization": []any{logging.Redacted},
+					"Token":         []any{logging.Redacted},
+					"Accept":        []any{"application/json"},
+				},
+			},
+		},
+		"nested_groups": {
+			log: func(logger *slog.Logger) {
+				logger.Info("test", slog.Group("request",
+					slog.String("entity", "users"),
+					slog.Group("auth", slog.String("authorization", "Bearer "+secret)),
+					slog.Any("headers", header),
+				))
+			},
+			want: map[string]any{
+				"request": map[string]any{
+					"entity": "users",
+					"auth":   map[string]any{"authorization": logging.Redacted},
+					"headers": map[string]any{
+						"Authorization": []any{logging.Redacted},
+						"Token":         []any{logging.Redacted},
+						"Accept":        []any{"application/json"},
+					},
+				},
+			},
+		},
+		"logger_groups": {
+			log: func(logger *slog.Logger) {
+				logger.WithGroup("datasource").With("token", secret).WithGroup("oauth").Info("test", "client_secret", secret)
+			},
+			want: map[string]any{
+				"datasource": map[string]any{
+					"token": logging.Redacted,
+					"oauth": map[string]any{"client_secret": logging.Redacted},
+				},
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			logger, err := logging.New(&buf, slog.LevelInfo, "json")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tt.log(logger)
+
+			if strings.Contains(buf.String(), secret) {
+				t.Fatalf("Secret is logged: %s", buf.String())
+			}
+
+			var got map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, key := range []string{slog.TimeKey, slog.LevelKey, slog.MessageKey} {
+				delete(got, key)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Got log %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Headers are redacted in a copy, and still authorize requests.
+	if got := header.Get("Authorization"); got != "Token token="+secret {
+		t.Errorf("Got Authorization header %q once logged, want it unchanged", got)
+	}
+}
+
+func TestRedactHeader(t *testing.T) {
+	header := http.Header{
+		"Authorization":   {"Token token=" + secret},
+		"X-Session-Token": {secret},
+		"X-Api-Key":       {secret},
+		"Content-Type":    {"application/json"},
+	}
+
+	want := http.Header{
+		"Authorization":   {logging.Redacted},
+		"X-Session-Token": {logging.Redacted},
+		"X-Api-Key":       {logging.Redacted},
+		"Content-Type":    {"application/json"},
+	}
+
+	if got := logging.RedactHeader(header); !reflect.DeepEqual(got, want) {
+		t.Errorf("Got header %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metrics defines the Prometheus metrics of the adapter.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "adapter"
+
+// CodeOK is the value of the code label of successful requests.
+const CodeOK = "OK"
+
+var (
+	// Registry is the registry of all the adapter metrics.
+	Registry = prometheus.NewRegistry()
+
+	// GetPageRequests counts the GetPage requests received by the adapter, by entity and error code.
+	// Failed requests are those whose code is not CodeOK.
+	GetPageRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "getpage_requests_total",
+		Help:      "Number of GetPage requests, by entity and error code.",
+	}, []string{"entity", "code"})
+
+	// GetPageDuration is the latency of the GetPage requests, by entity.
+	GetPageDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "getpage_duration_seconds",
+		Help:      "Latency of GetPage requests, by entity.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"entity"})
+
+	// GetPageObjects is the number of objects returned per page, by entity.
+	GetPageObjects = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "getpage_objects",
+		Help:      "Number of objects returned per page, by entity.",
+		Buckets:   []float64{0, 1, 10, 25, 50, 100, 250, 500, 1000},
+	}, []string{"entity"})
+
+	// DatasourceRequests counts the pages requested from the datasource, by entity and error code.
+	// Failed requests are those whose code is not CodeOK.
+	DatasourceRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "datasource_requests_total",
+		Help:      "Number of pages requested from the datasource, by entity and error code.",
+	}, []string{"entity", "code"})
+
+	// DatasourceDuration is the latency of the pages requested from the datasource, by entity.
+	// A page may require several HTTP requests.
+	DatasourceDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "datasource_duration_seconds",
+		Help:      "Latency of pages requested from the datasource, by entity.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"entity"})
+
+	// DatasourceHTTPDuration is the latency of the HTTP requests sent to the datasource, by entity
+	// and HTTP status code.
+	DatasourceHTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "datasource_http_request_duration_seconds",
+		Help:      "Latency of HTTP requests to the datasource, by entity and HTTP status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"entity", "status_code"})
+
+	// DatasourceResponseBytes is the size of the response bodies read from the datasource, by entity.
+	DatasourceResponseBytes = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "datasource_response_bytes",
+		Help:      "Size of the response bodies read from the datasource, by entity.",
+		Buckets:   prometheus.ExponentialBuckets(256, 4, 8),
+	}, []string{"entity"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		GetPageRequests,
+		GetPageDuration,
+		GetPageObjects,
+		DatasourceRequests,
+		DatasourceDuration,
+		DatasourceHTTPDuration,
+		DatasourceResponseBytes,
+	)
+}
+
+// Handler returns an HTTP handler that exposes the metrics in the Prometheus format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,35 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_test
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/metrics"
+)
+
+func TestRegistryLint(t *testing.T) {
+	metrics.GetPageRequests.WithLabelValues("users", metrics.CodeOK).Inc()
+
+	problems, err := testutil.GatherAndLint(metrics.Registry)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, problem := range problems {
+		t.Errorf("Metric %s: %s", problem.Metric, problem.Text)
+	}
+}
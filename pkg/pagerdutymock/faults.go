@@ -0,0 +1,124 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pagerdutymock
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Fault is a failure injected in the responses of the mock server, e.g. a 429 response, a 5xx
+// response or a slow response.
+type Fault struct {
+	// Path restricts the fault to the requests whose path, without the leading "/", starts with
+	// it, e.g. "users" or "audit/records". If empty, the fault applies to every request.
+	Path string
+
+	// Status is the status code of the response, e.g. 429 or 503. If 0, the request is served
+	// normally once delayed.
+	Status int
+
+	// RetryAfter is the value of the Retry-After header of the response, in seconds, if Status
+	// is set. If 0, no Retry-After header is returned.
+	RetryAfter int
+
+	// Delay is the duration the response is delayed by. The delay is interrupted if the
+	// request is cancelled.
+	Delay time.Duration
+
+	// Probability is the probability that the fault applies to a matching request, in (0, 1].
+	// If 0, the fault applies to every matching request.
+	Probability float64
+
+	// Times is the number of requests the fault applies to, after which it is removed.
+	// If 0, the fault applies until the faults are cleared.
+	Times int
+}
+
+// AddFault injects a fault in the responses of the server. Faults apply in the order they are
+// added, and at most one fault applies to a request.
+func (s *Server) AddFault(fault Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = append(s.faults, &fault)
+}
+
+// ClearFaults removes every injected fault.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = nil
+}
+
+// nextFault returns the fault that applies to a request to the path, or nil if none applies.
+func (s *Server) nextFault(path string) *Fault {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, fault := range s.faults {
+		if !strings.HasPrefix(path, fault.Path) {
+			continue
+		}
+
+		if fault.Probability > 0 && s.rand.Float64() >= fault.Probability {
+			continue
+		}
+
+		applied := *fault
+
+		if fault.Times > 0 {
+			fault.Times--
+
+			if fault.Times == 0 {
+				s.faults = append(s.faults[:i:i], s.faults[i+1:]...)
+			}
+		}
+
+		return &applied
+	}
+
+	return nil
+}
+
+// apply delays the response and writes the error response of the fault, if any.
+// Returns true if the response was written.
+func (f *Fault) apply(w http.ResponseWriter, r *http.Request) bool {
+	if f.Delay > 0 {
+		timer := time.NewTimer(f.Delay)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-r.Context().Done():
+			return true
+		}
+	}
+
+	if f.Status == 0 {
+		return false
+	}
+
+	if f.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(f.RetryAfter))
+	}
+
+	writeError(w, f.Status, statusError(f.Status, r))
+
+	return true
+}
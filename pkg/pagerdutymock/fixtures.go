@@ -0,0 +1,552 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pagerdutymock
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// Fixtures are the objects served by the mock server, by collection: "users", "contact_methods",
+// "teams", "vendors", "services", "business_services", "service_dependencies",
+// "escalation_policies", "schedules", "oncalls", "incidents", "log_entries" and "audit_records".
+// Objects are decoded JSON values, in the format returned by the PagerDuty REST API.
+// Service dependencies are the relationships returned for the business service of their
+// dependent_service.
+type Fixtures map[string][]map[string]any
+
+// Sizes are the numbers of objects generated by GenerateFixtures.
+type Sizes struct {
+	Users     int
+	Teams     int
+	Services  int
+	Schedules int
+	Incidents int
+
+	// AuditRecords is the number of audit records. Log entries are generated for every incident.
+	AuditRecords int
+}
+
+// DefaultSizes are the sizes of DefaultFixtures, which span several pages of PagerDuty's default
+// limit (25) for users, incidents, log entries and audit records.
+var DefaultSizes = Sizes{
+	Users:        60,
+	Teams:        5,
+	Services:     8,
+	Schedules:    3,
+	Incidents:    40,
+	AuditRecords: 75,
+}
+
+// FixturesEpoch is the creation time of the first generated object. Generated timestamps are
+// spread over the 30 days that follow it.
+var FixturesEpoch = time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// DefaultFixtures returns the fixtures generated with DefaultSizes and the seed.
+func DefaultFixtures(seed int64) Fixtures {
+	return GenerateFixtures(seed, DefaultSizes)
+}
+
+// LoadFixtures decodes fixtures from a JSON object of collections, e.g.
+// {"users": [{"id": "PXXXXXX", ...}], "teams": [...]}.
+func LoadFixtures(r io.Reader) (Fixtures, error) {
+	var fixtures Fixtures
+
+	if err := json.NewDecoder(r).Decode(&fixtures); err != nil {
+		return nil, fmt.Errorf("failed to decode fixtures: %w", err)
+	}
+
+	return fixtures, nil
+}
+
+var (
+	firstNames = []string{"Ada", "Alan", "Barbara", "Claude", "Dennis", "Edsger", "Frances", "Grace", "Hedy",
+		"John", "Ken", "Linus", "Margaret", "Niklaus", "Radia", "Shafi", "Tim", "Whitfield"}
+	lastNames = []string{"Allen", "Diffie", "Hamilton", "Hopper", "Kernighan", "Lamarr", "Liskov", "Lovelace",
+		"McCarthy", "Perlman", "Ritchie", "Shannon", "Thompson", "Torvalds", "Turing", "Wirth"}
+	teamNames    = []string{"Platform", "Payments", "Identity", "Data", "Mobile", "Search", "Security", "Support"}
+	serviceNames = []string{"API Gateway", "Billing", "Checkout", "Login", "Notifications", "Reporting",
+		"Search Index", "Storage", "Web Frontend", "Worker Queue"}
+	vendorNames   = []string{"Datadog", "Nagios", "Prometheus", "Splunk"}
+	timeZones     = []string{"America/Los_Angeles", "America/New_York", "Europe/London", "Asia/Tokyo"}
+	roles         = []string{"admin", "user", "limited_user", "observer", "owner"}
+	statuses      = []string{"triggered", "acknowledged", "resolved"}
+	urgencies     = []string{"high", "low"}
+	auditActions  = []string{"create", "update", "delete"}
+	resourceTypes = []string{"users", "teams", "services", "schedules", "escalation_policies"}
+)
+
+// GenerateFixtures returns fixtures of the given sizes, whose names, references, statuses and
+// timestamps are drawn from a pseudo-random source seeded with seed. The same seed and sizes
+// always generate the same fixtures.
+func GenerateFixtures(seed int64, sizes Sizes) Fixtures {
+	g := &generator{
+		rand:     rand.New(rand.NewSource(seed)),
+		fixtures: Fixtures{},
+	}
+
+	g.teams(sizes.Teams)
+	g.users(sizes.Users)
+	g.vendors()
+	g.escalationPolicies()
+	g.services(sizes.Services)
+	g.businessServices()
+	g.schedules(sizes.Schedules)
+	g.oncalls()
+	g.incidents(sizes.Incidents)
+	g.auditRecords(sizes.AuditRecords)
+
+	return g.fixtures
+}
+
+type generator struct {
+	rand     *rand.Rand
+	fixtures Fixtures
+}
+
+// id returns the PagerDuty-like ID of the i-th object whose IDs start with the prefix.
+func id(prefix byte, i int) string {
+	return fmt.Sprintf("P%c%05d", prefix, i+1)
+}
+
+// reference returns a reference to an object, as embedded in other objects.
+func reference(object map[string]any) map[string]any {
+	ref := map[string]any{
+		"id":      object["id"],
+		"type":    fmt.Sprintf("%s_reference", object["type"]),
+		"summary": object["summary"],
+		"self":    object["self"],
+	}
+
+	if htmlURL, found := object["html_url"]; found {
+		ref["html_url"] = htmlURL
+	}
+
+	return ref
+}
+
+// references returns references to the objects.
+func references(objects ...map[string]any) []any {
+	refs := make([]any, 0, len(objects))
+	for _, object := range objects {
+		refs = append(refs, reference(object))
+	}
+
+	return refs
+}
+
+// object returns the common attributes of an object of the collection.
+func object(collection, objectType, objectID, summary string) map[string]any {
+	return map[string]any{
+		"id":       objectID,
+		"type":     objectType,
+		"summary":  summary,
+		"self":     fmt.Sprintf("https://api.pagerduty.com/%s/%s", collection, objectID),
+		"html_url": fmt.Sprintf("https://example.pagerduty.com/%s/%s", collection, objectID),
+	}
+}
+
+func (g *generator) pick(values []string) string {
+	return values[g.rand.Intn(len(values))]
+}
+
+func (g *generator) pickObject(collection string) map[string]any {
+	objects := g.fixtures[collection]
+
+	return objects[g.rand.Intn(len(objects))]
+}
+
+// timestamp returns an RFC3339 timestamp after FixturesEpoch, ordered by i out of n.
+func (g *generator) timestamp(i, n int) string {
+	span := 30 * 24 * time.Hour / time.Duration(n+1)
+	jitter := time.Duration(g.rand.Int63n(int64(span / 2)))
+
+	return FixturesEpoch.Add(time.Duration(i)*span + jitter).Format(time.RFC3339)
+}
+
+func (g *generator) teams(n int) {
+	for i := 0; i < n; i++ {
+		name := teamNames[i%len(teamNames)]
+		if i >= len(teamNames) {
+			name = fmt.Sprintf("%s %d", name, i/len(teamNames)+1)
+		}
+
+		team := object("teams", "team", id('T', i), name)
+		team["name"] = name
+		team["description"] = fmt.Sprintf("The %s team.", name)
+		team["default_role"] = "manager"
+		team["parent"] = nil
+
+		g.fixtures["teams"] = append(g.fixtures["teams"], team)
+	}
+}
+
+func (g *generator) users(n int) {
+	for i := 0; i < n; i++ {
+		first, last := g.pick(firstNames), g.pick(lastNames)
+		name := fmt.Sprintf("%s %s", first, last)
+		email := fmt.Sprintf("%s.%s.%d@example.com", strings.ToLower(first), strings.ToLower(last), i+1)
+
+		user := object("users", "user", id('U', i), name)
+		user["name"] = name
+		user["email"] = email
+		user["time_zone"] = g.pick(timeZones)
+		user["color"] = "purple"
+		user["role"] = g.pick(roles)
+		user["avatar_url"] = fmt.Sprintf("https://secure.gravatar.com/avatar/%d.png", i+1)
+		user["description"] = ""
+		user["invitation_sent"] = false
+		user["job_title"] = "Engineer"
+
+		contactMethod := object("contact_methods", "email_contact_method", id('C', i), "Default")
+		contactMethod["label"] = "Default"
+		contactMethod["address"] = email
+		contactMethod["send_short_email"] = false
+		delete(contactMethod, "html_url")
+		g.fixtures["contact_methods"] = append(g.fixtures["contact_methods"], contactMethod)
+
+		user["contact_methods"] = references(contactMethod)
+		user["notification_rules"] = []any{}
+
+		if teams := g.fixtures["teams"]; len(teams) > 0 {
+			user["teams"] = references(teams[i%len(teams)])
+		} else {
+			user["teams"] = []any{}
+		}
+
+		g.fixtures["users"] = append(g.fixtures["users"], user)
+	}
+}
+
+func (g *generator) vendors() {
+	for i, name := range vendorNames {
+		vendor := object("vendors", "vendor", id('V', i), name)
+		vendor["name"] = name
+		vendor["website_url"] = fmt.Sprintf("https://www.%s.com", strings.ToLower(name))
+		vendor["description"] = fmt.Sprintf("Integration with %s.", name)
+		vendor["generic_service_type"] = "api"
+		vendor["integration_guide_url"] = nil
+		vendor["alert_creation_default"] = "create_alerts_and_incidents"
+
+		g.fixtures["vendors"] = append(g.fixtures["vendors"], vendor)
+	}
+}
+
+func (g *generator) escalationPolicies() {
+	for i, team := range g.fixtures["teams"] {
+		name := fmt.Sprintf("%s Escalation Policy", team["name"])
+
+		policy := object("escalation_policies", "escalation_policy", id('E', i), name)
+		policy["name"] = name
+		policy["description"] = ""
+		policy["num_loops"] = 0
+		policy["on_call_handoff_notifications"] = "if_has_services"
+		policy["teams"] = references(team)
+
+		rules := []any{}
+
+		if len(g.fixtures["users"]) > 0 {
+			for level := 0; level < 2; level++ {
+				rules = append(rules, map[string]any{
+					"id":                          id('R', i*2+level),
+					"escalation_delay_in_minutes": 30,
+					"targets":                     references(g.pickObject("users")),
+				})
+			}
+		}
+
+		policy["escalation_rules"] = rules
+
+		g.fixtures["escalation_policies"] = append(g.fixtures["escalation_policies"], policy)
+	}
+}
+
+func (g *generator) services(n int) {
+	for i := 0; i < n; i++ {
+		name := serviceNames[i%len(serviceNames)]
+		if i >= len(serviceNames) {
+			name = fmt.Sprintf("%s %d", name, i/len(serviceNames)+1)
+		}
+
+		service := object("services", "service", id('S', i), name)
+		service["name"] = name
+		service["description"] = fmt.Sprintf("The %s service.", name)
+		service["status"] = "active"
+		service["created_at"] = g.timestamp(i, n)
+		service["auto_resolve_timeout"] = 14400
+		service["acknowledgement_timeout"] = 600
+		service["alert_creation"] = "create_alerts_and_incidents"
+
+		if policies := g.fixtures["escalation_policies"]; len(policies) > 0 {
+			policy := policies[i%len(policies)]
+			service["escalation_policy"] = reference(policy)
+			service["teams"] = clone(policy["teams"])
+		} else {
+			service["escalation_policy"] = nil
+			service["teams"] = []any{}
+		}
+
+		g.fixtures["services"] = append(g.fixtures["services"], service)
+	}
+}
+
+func (g *generator) businessServices() {
+	services := g.fixtures["services"]
+	n := (len(services) + 2) / 3
+
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("Business Service %d", i+1)
+
+		businessService := object("business_services", "business_service", id('B', i), name)
+		businessService["name"] = name
+		businessService["description"] = fmt.Sprintf("Customer facing capability %d.", i+1)
+		businessService["point_of_contact"] = fmt.Sprintf("owner-%d@example.com", i+1)
+
+		if teams := g.fixtures["teams"]; len(teams) > 0 {
+			businessService["team"] = reference(teams[i%len(teams)])
+		} else {
+			businessService["team"] = nil
+		}
+
+		g.fixtures["business_services"] = append(g.fixtures["business_services"], businessService)
+
+		// Every business service depends on up to 3 technical services.
+		for j := i * 3; j < i*3+3 && j < len(services); j++ {
+			g.fixtures["service_dependencies"] = append(g.fixtures["service_dependencies"], map[string]any{
+				"id":   id('D', j),
+				"type": "service_dependency",
+				"supporting_service": map[string]any{
+					"id":   services[j]["id"],
+					"type": "technical_service_reference",
+				},
+				"dependent_service": map[string]any{
+					"id":   businessService["id"],
+					"type": "business_service_reference",
+				},
+			})
+		}
+	}
+
+	// The last business service also depends on the first, so that a dependency is returned for
+	// both of its business services.
+	if businessServices := g.fixtures["business_services"]; len(businessServices) > 1 {
+		g.fixtures["service_dependencies"] = append(g.fixtures["service_dependencies"], map[string]any{
+			"id":   id('D', len(services)),
+			"type": "service_dependency",
+			"supporting_service": map[string]any{
+				"id":   businessServices[0]["id"],
+				"type": "business_service_reference",
+			},
+			"dependent_service": map[string]any{
+				"id":   businessServices[len(businessServices)-1]["id"],
+				"type": "business_service_reference",
+			},
+		})
+	}
+}
+
+func (g *generator) schedules(n int) {
+	users := g.fixtures["users"]
+
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("Primary On-Call %d", i+1)
+
+		schedule := object("schedules", "schedule", id('H', i), name)
+		schedule["name"] = name
+		schedule["description"] = ""
+		schedule["time_zone"] = g.pick(timeZones)
+
+		var members []map[string]any
+		for j := i; j < len(users) && len(members) < 4; j += n {
+			members = append(members, users[j])
+		}
+
+		schedule["users"] = references(members...)
+
+		if policies := g.fixtures["escalation_policies"]; len(policies) > 0 {
+			policy := policies[i%len(policies)]
+			schedule["escalation_policies"] = references(policy)
+			schedule["teams"] = clone(policy["teams"])
+		} else {
+			schedule["escalation_policies"] = []any{}
+			schedule["teams"] = []any{}
+		}
+
+		g.fixtures["schedules"] = append(g.fixtures["schedules"], schedule)
+	}
+}
+
+func (g *generator) oncalls() {
+	schedules := g.fixtures["schedules"]
+	policies := g.fixtures["escalation_policies"]
+
+	if len(schedules) == 0 || len(policies) == 0 {
+		return
+	}
+
+	for i, schedule := range schedules {
+		members, _ := schedule["users"].([]any)
+
+		// One on-call shift a week, for 4 weeks, rotating through the members of the schedule.
+		for week := 0; week < 4 && len(members) > 0; week++ {
+			start := FixturesEpoch.Add(time.Duration(week) * 7 * 24 * time.Hour)
+
+			g.fixtures["oncalls"] = append(g.fixtures["oncalls"], map[string]any{
+				"escalation_policy": reference(policies[i%len(policies)]),
+				"escalation_level":  1,
+				"schedule":          reference(schedule),
+				"user":              clone(members[week%len(members)]),
+				"start":             start.Format(time.RFC3339),
+				"end":               start.Add(7 * 24 * time.Hour).Format(time.RFC3339),
+			})
+		}
+	}
+}
+
+func (g *generator) incidents(n int) {
+	if len(g.fixtures["services"]) == 0 {
+		return
+	}
+
+	for i := 0; i < n; i++ {
+		service := g.pickObject("services")
+		title := fmt.Sprintf("%s is degraded", service["name"])
+
+		incident := object("incidents", "incident", id('I', i), fmt.Sprintf("[#%d] %s", i+1, title))
+		incident["incident_number"] = i + 1
+		incident["incident_key"] = fmt.Sprintf("incident-%d", i+1)
+		incident["title"] = title
+		incident["description"] = title
+		incident["status"] = g.pick(statuses)
+		incident["urgency"] = g.pick(urgencies)
+		incident["created_at"] = g.timestamp(i, n)
+		incident["service"] = reference(service)
+		incident["escalation_policy"] = clone(service["escalation_policy"])
+		incident["teams"] = clone(service["teams"])
+
+		assignments := []any{}
+		if incident["status"] != "resolved" && len(g.fixtures["users"]) > 0 {
+			assignments = append(assignments, map[string]any{
+				"at":       incident["created_at"],
+				"assignee": reference(g.pickObject("users")),
+			})
+		}
+
+		incident["assignments"] = assignments
+
+		g.fixtures["incidents"] = append(g.fixtures["incidents"], incident)
+
+		g.logEntries(incident)
+	}
+}
+
+// logEntries generates the log entries of an incident, up to its current status.
+func (g *generator) logEntries(incident map[string]any) {
+	created, _ := time.Parse(time.RFC3339, incident["created_at"].(string))
+
+	types := []string{"trigger_log_entry", "notify_log_entry"}
+
+	switch incident["status"] {
+	case "acknowledged":
+		types = append(types, "acknowledge_log_entry")
+	case "resolved":
+		types = append(types, "acknowledge_log_entry", "resolve_log_entry")
+	}
+
+	for i, logEntryType := range types {
+		n := len(g.fixtures["log_entries"])
+		summary := strings.ReplaceAll(strings.TrimSuffix(logEntryType, "_log_entry"), "_", " ")
+
+		logEntry := object("log_entries", logEntryType, id('L', n), fmt.Sprintf("%s %s", summary, incident["title"]))
+		logEntry["created_at"] = created.Add(time.Duration(i) * 5 * time.Minute).Format(time.RFC3339)
+		logEntry["incident"] = reference(incident)
+		logEntry["service"] = clone(incident["service"])
+		logEntry["teams"] = clone(incident["teams"])
+		logEntry["contexts"] = []any{}
+
+		switch logEntryType {
+		case "trigger_log_entry":
+			logEntry["agent"] = clone(incident["service"])
+			logEntry["channel"] = map[string]any{
+				"type":    "api",
+				"summary": incident["title"],
+				"subject": incident["title"],
+			}
+		case "notify_log_entry":
+			user := g.pickObject("users")
+			logEntry["agent"] = reference(user)
+			logEntry["channel"] = map[string]any{
+				"type":    "email",
+				"summary": fmt.Sprintf("Email to %s", user["email"]),
+				"to":      user["email"],
+			}
+		default:
+			logEntry["agent"] = reference(g.pickObject("users"))
+			logEntry["channel"] = map[string]any{
+				"type":    "web_ui",
+				"summary": "Website",
+			}
+		}
+
+		g.fixtures["log_entries"] = append(g.fixtures["log_entries"], logEntry)
+	}
+}
+
+func (g *generator) auditRecords(n int) {
+	if len(g.fixtures["users"]) == 0 {
+		return
+	}
+
+	// Audit records are returned from the most recent.
+	for i := n - 1; i >= 0; i-- {
+		actor := g.pickObject("users")
+		resourceType := g.pick(resourceTypes)
+		action := g.pick(auditActions)
+
+		var root map[string]any
+		if objects := g.fixtures[resourceType]; len(objects) > 0 {
+			root = reference(objects[g.rand.Intn(len(objects))])
+		} else {
+			root = reference(actor)
+		}
+
+		g.fixtures["audit_records"] = append(g.fixtures["audit_records"], map[string]any{
+			"id":             fmt.Sprintf("%08x-0000-4000-8000-%012x", i+1, i+1),
+			"self":           nil,
+			"execution_time": g.timestamp(i, n),
+			"execution_context": map[string]any{
+				"request_id":     fmt.Sprintf("request-%d", i+1),
+				"remote_address": fmt.Sprintf("192.0.2.%d", i%250+1),
+			},
+			"actors": []any{reference(actor)},
+			"method": map[string]any{
+				"type":            "api_token",
+				"truncated_token": "abcd",
+			},
+			"root_resource": root,
+			"action":        action,
+			"details": map[string]any{
+				"resource": root,
+				"fields": []any{
+					map[string]any{"name": "name", "before_value": nil, "value": root["summary"]},
+				},
+			},
+		})
+	}
+}
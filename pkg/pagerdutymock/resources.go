@@ -0,0 +1,340 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pagerdutymock
+
+import (
+	"fmt"
+	"strings"
+)
+
+// resource is a list endpoint of the PagerDuty REST API.
+type resource struct {
+	// path is the path of the endpoint, without the leading "/".
+	path string
+
+	// key is the field of the response that contains the list of objects.
+	key string
+
+	// collection is the fixtures collection listed by the endpoint.
+	collection string
+
+	// cursorPagination is true if the endpoint is paginated with cursor and next_cursor instead of
+	// offset, limit and more.
+	cursorPagination bool
+
+	// maxLimit is the maximum page size of the endpoint.
+	// Defaults to MaxLimit.
+	maxLimit int
+
+	// timeAttribute is the attribute filtered by the since and until parameters, if supported.
+	timeAttribute string
+
+	// filters are the filters of the endpoint, by query parameter name. Array parameters are named
+	// without the "[]" suffix, which is optional in requests.
+	filters map[string]filter
+
+	// includes are the values of the include[] parameter supported by the endpoint.
+	includes map[string]include
+}
+
+// filter returns true if an object matches the values of a query parameter.
+type filter func(object map[string]any, values []string) bool
+
+// include is a value of the include[] parameter, which replaces references by full objects.
+type include struct {
+	// path is the "." delimited path of the references in the object.
+	path string
+
+	// collection is the fixtures collection of the referenced objects.
+	collection string
+}
+
+// resources are the list endpoints served by the mock server.
+var resources = []*resource{
+	{
+		path:       "users",
+		key:        "users",
+		collection: "users",
+		filters: map[string]filter{
+			"query":    queryFilter("name", "email"),
+			"team_ids": valuesFilter("teams.id"),
+		},
+		includes: map[string]include{
+			"contact_methods": {path: "contact_methods", collection: "contact_methods"},
+			"teams":           {path: "teams", collection: "teams"},
+		},
+	},
+	{
+		path:       "vendors",
+		key:        "vendors",
+		collection: "vendors",
+		filters: map[string]filter{
+			"query": queryFilter("name"),
+		},
+	},
+	{
+		path:       "teams",
+		key:        "teams",
+		collection: "teams",
+		filters: map[string]filter{
+			"query": queryFilter("name"),
+		},
+	},
+	{
+		path:       "services",
+		key:        "services",
+		collection: "services",
+		filters: map[string]filter{
+			"query":    queryFilter("name"),
+			"team_ids": valuesFilter("teams.id"),
+		},
+		includes: map[string]include{
+			"escalation_policies": {path: "escalation_policy", collection: "escalation_policies"},
+			"teams":               {path: "teams", collection: "teams"},
+		},
+	},
+	{
+		path:       "business_services",
+		key:        "business_services",
+		collection: "business_services",
+	},
+	{
+		path:       "escalation_policies",
+		key:        "escalation_policies",
+		collection: "escalation_policies",
+		filters: map[string]filter{
+			"query":    queryFilter("name"),
+			"team_ids": valuesFilter("teams.id"),
+		},
+		includes: map[string]include{
+			"teams": {path: "teams", collection: "teams"},
+		},
+	},
+	{
+		path:       "schedules",
+		key:        "schedules",
+		collection: "schedules",
+		filters: map[string]filter{
+			"query": queryFilter("name"),
+		},
+	},
+	{
+		path:       "oncalls",
+		key:        "oncalls",
+		collection: "oncalls",
+		filters: map[string]filter{
+			"user_ids":              valuesFilter("user.id"),
+			"schedule_ids":          valuesFilter("schedule.id"),
+			"escalation_policy_ids": valuesFilter("escalation_policy.id"),
+		},
+		includes: map[string]include{
+			"users":               {path: "user", collection: "users"},
+			"schedules":           {path: "schedule", collection: "schedules"},
+			"escalation_policies": {path: "escalation_policy", collection: "escalation_policies"},
+		},
+	},
+	{
+		path:          "incidents",
+		key:           "incidents",
+		collection:    "incidents",
+		timeAttribute: "created_at",
+		filters: map[string]filter{
+			"statuses":    valuesFilter("status"),
+			"urgencies":   valuesFilter("urgency"),
+			"service_ids": valuesFilter("service.id"),
+			"team_ids":    valuesFilter("teams.id"),
+			"user_ids":    valuesFilter("assignments.assignee.id"),
+		},
+		includes: map[string]include{
+			"assignees":           {path: "assignments.assignee", collection: "users"},
+			"escalation_policies": {path: "escalation_policy", collection: "escalation_policies"},
+			"services":            {path: "service", collection: "services"},
+			"teams":               {path: "teams", collection: "teams"},
+		},
+	},
+	{
+		path:          "log_entries",
+		key:           "log_entries",
+		collection:    "log_entries",
+		timeAttribute: "created_at",
+		filters: map[string]filter{
+			"is_overview": overviewFilter,
+			"team_ids":    valuesFilter("teams.id"),
+		},
+		includes: map[string]include{
+			"incidents": {path: "incident", collection: "incidents"},
+			"services":  {path: "service", collection: "services"},
+			"teams":     {path: "teams", collection: "teams"},
+		},
+	},
+	{
+		path:             "audit/records",
+		key:              "records",
+		collection:       "audit_records",
+		cursorPagination: true,
+		maxLimit:         1000,
+		timeAttribute:    "execution_time",
+		filters: map[string]filter{
+			"root_resource_types": valuesFilter("root_resource.type"),
+			"actor_id":            valuesFilter("actors.id"),
+		},
+	},
+}
+
+// serviceDependenciesPath is the path prefix of the endpoint that returns the dependencies of a
+// business service, followed by the ID of the business service.
+const serviceDependenciesPath = "service_dependencies/business_services/"
+
+// overviewLogEntryTypes are the types of the log entries returned when is_overview is true.
+var overviewLogEntryTypes = map[string]struct{}{
+	"trigger_log_entry":     {},
+	"acknowledge_log_entry": {},
+	"resolve_log_entry":     {},
+}
+
+// queryFilter returns a filter that matches objects whose attributes contain the value of the
+// query parameter, case-insensitively.
+func queryFilter(attributes ...string) filter {
+	return func(object map[string]any, values []string) bool {
+		query := strings.ToLower(values[0])
+
+		for _, attribute := range attributes {
+			if value, ok := object[attribute].(string); ok && strings.Contains(strings.ToLower(value), query) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// valuesFilter returns a filter that matches objects that have at least one of the values at the
+// "." delimited path.
+func valuesFilter(path string) filter {
+	return func(object map[string]any, values []string) bool {
+		for _, found := range collect(object, strings.Split(path, ".")) {
+			for _, value := range values {
+				if fmt.Sprint(found) == value {
+					return true
+				}
+			}
+		}
+
+		return false
+	}
+}
+
+// overviewFilter matches the overview log entries if is_overview is true.
+func overviewFilter(object map[string]any, values []string) bool {
+	if values[0] != "true" {
+		return true
+	}
+
+	logEntryType, _ := object["type"].(string)
+	_, found := overviewLogEntryTypes[logEntryType]
+
+	return found
+}
+
+// collect returns the values at the "." delimited path in value, traversing arrays.
+func collect(value any, path []string) []any {
+	switch v := value.(type) {
+	case []any:
+		var values []any
+		for _, item := range v {
+			values = append(values, collect(item, path)...)
+		}
+
+		return values
+	case map[string]any:
+		if len(path) == 0 {
+			return []any{v}
+		}
+
+		child, found := v[path[0]]
+		if !found || child == nil {
+			return nil
+		}
+
+		return collect(child, path[1:])
+	default:
+		if len(path) == 0 {
+			return []any{v}
+		}
+
+		return nil
+	}
+}
+
+// expand replaces the references at the "." delimited path in object, traversing arrays, by the
+// objects returned by lookup. References whose object is not found are kept.
+func expand(object map[string]any, path []string, lookup func(id string) map[string]any) {
+	child, found := object[path[0]]
+	if !found {
+		return
+	}
+
+	replace := func(value any) any {
+		reference, ok := value.(map[string]any)
+		if !ok {
+			return value
+		}
+
+		if len(path) > 1 {
+			expand(reference, path[1:], lookup)
+
+			return reference
+		}
+
+		id, _ := reference["id"].(string)
+		if full := lookup(id); full != nil {
+			return full
+		}
+
+		return reference
+	}
+
+	if items, ok := child.([]any); ok {
+		for i, item := range items {
+			items[i] = replace(item)
+		}
+
+		return
+	}
+
+	object[path[0]] = replace(child)
+}
+
+// clone returns a deep copy of a JSON value.
+func clone(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		copied := make(map[string]any, len(v))
+		for key, item := range v {
+			copied[key] = clone(item)
+		}
+
+		return copied
+	case []any:
+		copied := make([]any, len(v))
+		for i, item := range v {
+			copied[i] = clone(item)
+		}
+
+		return copied
+	default:
+		return v
+	}
+}
@@ -0,0 +1,446 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pagerdutymock implements a mock of the PagerDuty REST API, which serves the list
+// endpoints queried by the adapter from fixtures, to test and demo the adapter without a
+// PagerDuty account.
+//
+// The server honors limit, offset and more (or cursor and next_cursor for audit records),
+// include[], since and until, and the main filters of each endpoint. Faults such as 429, 5xx and
+// slow responses can be injected with AddFault.
+//
+// The server is an http.Handler, which can be served by httptest, or by NewTestServer in tests:
+//
+//	mock := pagerdutymock.NewTestServer(t, nil)
+//	client := adapter.NewClient(10, nil, adapter.WithTransport(mock.Client.Transport))
+package pagerdutymock
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultLimit is the page size returned if the request has no limit parameter.
+	DefaultLimit = 25
+
+	// MaxLimit is the maximum page size of endpoints, unless they define their own. Larger limits
+	// are reduced to it.
+	MaxLimit = 100
+)
+
+// APIError is the error returned in the body of unsuccessful responses, as {"error": APIError}.
+type APIError struct {
+	Message string   `json:"message"`
+	Code    int      `json:"code"`
+	Errors  []string `json:"errors,omitempty"`
+}
+
+// Request is a request received by the server.
+type Request struct {
+	Method string
+	URL    *url.URL
+	Header http.Header
+}
+
+// Server is a mock of the PagerDuty REST API.
+type Server struct {
+	// Token is the API key or OAuth token accepted by the server, sent as "Token token=<Token>" or
+	// "Bearer <Token>". If empty, any Authorization header is accepted, but it must be set.
+	Token string
+
+	fixtures Fixtures
+
+	// index is the index of the fixtures by collection and ID, to include referenced objects.
+	index map[string]map[string]map[string]any
+
+	mu       sync.Mutex
+	rand     *rand.Rand
+	faults   []*Fault
+	requests []Request
+}
+
+// New returns a mock server that serves the fixtures.
+func New(fixtures Fixtures) *Server {
+	s := &Server{
+		fixtures: fixtures,
+		index:    make(map[string]map[string]map[string]any, len(fixtures)),
+		rand:     rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+
+	for collection, objects := range fixtures {
+		s.index[collection] = make(map[string]map[string]any, len(objects))
+
+		for _, object := range objects {
+			if objectID, ok := object["id"].(string); ok {
+				s.index[collection][objectID] = object
+			}
+		}
+	}
+
+	return s
+}
+
+// Requests returns the requests received by the server, in order.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Request(nil), s.requests...)
+}
+
+// ServeHTTP serves a request to the PagerDuty REST API.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(r.URL.Path, "/")
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{Method: r.Method, URL: r.URL, Header: r.Header.Clone()})
+	s.mu.Unlock()
+
+	if fault := s.nextFault(path); fault != nil && fault.apply(w, r) {
+		return
+	}
+
+	if !s.authorized(r) {
+		writeError(w, http.StatusUnauthorized, statusError(http.StatusUnauthorized, r))
+
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, &APIError{Message: "Method Not Allowed", Code: 2000})
+
+		return
+	}
+
+	if businessServiceID, found := strings.CutPrefix(path, serviceDependenciesPath); found {
+		s.serveServiceDependencies(w, businessServiceID)
+
+		return
+	}
+
+	for _, res := range resources {
+		if res.path == path {
+			s.serveList(w, r, res)
+
+			return
+		}
+	}
+
+	writeError(w, http.StatusNotFound, statusError(http.StatusNotFound, r))
+}
+
+// authorized returns true if the request has a valid Authorization header.
+func (s *Server) authorized(r *http.Request) bool {
+	authorization := r.Header.Get("Authorization")
+
+	if s.Token == "" {
+		return authorization != ""
+	}
+
+	return authorization == "Token token="+s.Token || authorization == "Bearer "+s.Token
+}
+
+// serveList serves a page of the objects of a list endpoint.
+func (s *Server) serveList(w http.ResponseWriter, r *http.Request, res *resource) {
+	query := r.URL.Query()
+
+	limit, err := intParam(query, "limit", DefaultLimit)
+	if err != nil || limit < 1 {
+		writeError(w, http.StatusBadRequest, invalidInput("limit must be a positive integer"))
+
+		return
+	}
+
+	maxLimit := MaxLimit
+	if res.maxLimit > 0 {
+		maxLimit = res.maxLimit
+	}
+
+	limit = min(limit, maxLimit)
+
+	offset, err := s.offset(query, res)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, invalidInput(err.Error()))
+
+		return
+	}
+
+	includes := arrayParam(query, "include")
+	for _, name := range includes {
+		if _, found := res.includes[name]; !found {
+			writeError(w, http.StatusBadRequest, invalidInput(fmt.Sprintf("include[] contains unsupported value %q", name)))
+
+			return
+		}
+	}
+
+	matches, err := s.filter(query, res)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, invalidInput(err.Error()))
+
+		return
+	}
+
+	end := min(offset+limit, len(matches))
+	if offset > end {
+		offset = end
+	}
+
+	page := make([]any, 0, end-offset)
+
+	for _, object := range matches[offset:end] {
+		copied := clone(object).(map[string]any)
+
+		for _, name := range includes {
+			inc := res.includes[name]
+			expand(copied, strings.Split(inc.path, "."), func(id string) map[string]any {
+				if full, found := s.index[inc.collection][id]; found {
+					return clone(full).(map[string]any)
+				}
+
+				return nil
+			})
+		}
+
+		page = append(page, copied)
+	}
+
+	body := map[string]any{
+		res.key: page,
+		"limit": limit,
+	}
+
+	if res.cursorPagination {
+		var nextCursor any
+		if end < len(matches) {
+			nextCursor = encodeCursor(end)
+		}
+
+		body["next_cursor"] = nextCursor
+	} else {
+		var total any
+		if query.Get("total") == "true" {
+			total = len(matches)
+		}
+
+		body["offset"] = offset
+		body["more"] = end < len(matches)
+		body["total"] = total
+	}
+
+	writeJSON(w, http.StatusOK, body)
+}
+
+// offset returns the offset of the requested page, from the offset or cursor parameter.
+func (s *Server) offset(query url.Values, res *resource) (int, error) {
+	if res.cursorPagination {
+		cursor := query.Get("cursor")
+		if cursor == "" {
+			return 0, nil
+		}
+
+		offset, err := decodeCursor(cursor)
+		if err != nil {
+			return 0, fmt.Errorf("cursor %q is invalid", cursor)
+		}
+
+		return offset, nil
+	}
+
+	offset, err := intParam(query, "offset", 0)
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("offset must be a non-negative integer")
+	}
+
+	return offset, nil
+}
+
+// filter returns the objects of the endpoint that match the filters of the request.
+func (s *Server) filter(query url.Values, res *resource) ([]map[string]any, error) {
+	var since, until time.Time
+
+	if res.timeAttribute != "" {
+		var err error
+
+		if since, err = timeParam(query, "since"); err != nil {
+			return nil, err
+		}
+
+		if until, err = timeParam(query, "until"); err != nil {
+			return nil, err
+		}
+	}
+
+	var matches []map[string]any
+
+objects:
+	for _, object := range s.fixtures[res.collection] {
+		if res.timeAttribute != "" && (!since.IsZero() || !until.IsZero()) {
+			value, _ := object[res.timeAttribute].(string)
+
+			at, err := time.Parse(time.RFC3339, value)
+			if err != nil || (!since.IsZero() && at.Before(since)) || (!until.IsZero() && !at.Before(until)) {
+				continue
+			}
+		}
+
+		for name, matchFilter := range res.filters {
+			if values := arrayParam(query, name); len(values) > 0 && !matchFilter(object, values) {
+				continue objects
+			}
+		}
+
+		matches = append(matches, object)
+	}
+
+	return matches, nil
+}
+
+// serveServiceDependencies serves the relationships of a business service, as PagerDuty, both
+// those in which it is the dependent service and those in which it is the supporting service.
+func (s *Server) serveServiceDependencies(w http.ResponseWriter, businessServiceID string) {
+	if _, found := s.index["business_services"][businessServiceID]; !found {
+		writeError(w, http.StatusNotFound, &APIError{Message: "Not Found", Code: 2100})
+
+		return
+	}
+
+	relationships := []any{}
+
+	for _, relationship := range s.fixtures["service_dependencies"] {
+		serviceIDs := append(
+			collect(relationship, []string{"dependent_service", "id"}),
+			collect(relationship, []string{"supporting_service", "id"})...,
+		)
+
+		for _, serviceID := range serviceIDs {
+			if serviceID == businessServiceID {
+				relationships = append(relationships, clone(relationship))
+
+				break
+			}
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"relationships": relationships})
+}
+
+// intParam returns the value of an integer query parameter, or def if it is not set.
+func intParam(query url.Values, name string, def int) (int, error) {
+	value := query.Get(name)
+	if value == "" {
+		return def, nil
+	}
+
+	return strconv.Atoi(value)
+}
+
+// timeParam returns the value of an RFC3339 query parameter, or the zero time if it is not set.
+func timeParam(query url.Values, name string) (time.Time, error) {
+	value := query.Get(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	at, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be an ISO 8601 timestamp", name)
+	}
+
+	return at, nil
+}
+
+// arrayParam returns the values of an array query parameter, named with or without "[]".
+func arrayParam(query url.Values, name string) []string {
+	return append(query[name+"[]"], query[name]...)
+}
+
+func encodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("offset:" + strconv.Itoa(offset)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+
+	offset, found := strings.CutPrefix(string(decoded), "offset:")
+	if !found {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+
+	return strconv.Atoi(offset)
+}
+
+// invalidInput returns the error of a request with invalid parameters.
+func invalidInput(message string) *APIError {
+	return &APIError{Message: "Invalid Input Provided", Code: 2001, Errors: []string{message}}
+}
+
+// statusError returns the error returned by PagerDuty with the status code.
+func statusError(status int, r *http.Request) *APIError {
+	switch status {
+	case http.StatusUnauthorized:
+		return &APIError{Message: "Unauthorized", Code: 2006}
+	case http.StatusForbidden:
+		return &APIError{
+			Message: "Access Denied",
+			Code:    2010,
+			Errors:  []string{fmt.Sprintf("Access token does not have the required scope %s", requiredScope(r.URL.Path))},
+		}
+	case http.StatusNotFound:
+		return &APIError{Message: "Not Found", Code: 2100}
+	case http.StatusTooManyRequests:
+		return &APIError{Message: "Rate Limit Exceeded", Code: 2020}
+	default:
+		return &APIError{Message: http.StatusText(status), Code: 2000}
+	}
+}
+
+// requiredScope returns the OAuth scope required to read the endpoint at the path.
+func requiredScope(path string) string {
+	resourceType, _, _ := strings.Cut(strings.Trim(path, "/"), "/")
+
+	switch resourceType {
+	case "business_services", "service_dependencies":
+		return "services.read"
+	case "log_entries":
+		return "incidents.read"
+	case "audit":
+		return "audit_records.read"
+	default:
+		return resourceType + ".read"
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, apiErr *APIError) {
+	writeJSON(w, status, map[string]any{"error": apiErr})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,462 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pagerdutymock_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/tksarunachalam/sgnl-adapter/pkg/pagerdutymock"
+)
+
+// get sends a GET request to the server with the query, and returns the status code, headers and
+// decoded body of the response.
+func get(t *testing.T, server *pagerdutymock.TestServer, path string, query url.Values) (int, http.Header, map[string]any) {
+	t.Helper()
+
+	request, err := http.NewRequest(http.MethodGet, server.URL+"/"+path+"?"+query.Encode(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request.Header.Set("Authorization", "Token token=test")
+
+	response, err := server.Client.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer response.Body.Close()
+
+	var body map[string]any
+	if err := json.NewDecoder(response.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+
+	return response.StatusCode, response.Header, body
+}
+
+// objectIDs returns the IDs of the objects of a response or of fixtures.
+func objectIDs[T any](objects []T) []string {
+	ids := make([]string, 0, len(objects))
+
+	for _, object := range objects {
+		id, _ := any(object).(map[string]any)["id"].(string)
+		ids = append(ids, id)
+	}
+
+	return ids
+}
+
+func objects(body map[string]any, key string) []any {
+	list, _ := body[key].([]any)
+
+	return list
+}
+
+func TestServerOffsetPaging(t *testing.T) {
+	fixtures := pagerdutymock.DefaultFixtures(1)
+	server := pagerdutymock.NewTestServer(t, fixtures)
+
+	var got []string
+
+	for offset := 0; ; {
+		status, _, body := get(t, server, "users", url.Values{"limit": {"25"}, "offset": {strconv.Itoa(offset)}, "total": {"true"}})
+		if status != http.StatusOK {
+			t.Fatalf("offset %d: got status %d: %v", offset, status, body)
+		}
+
+		page := objects(body, "users")
+		if len(page) > 25 {
+			t.Errorf("offset %d: got %d users, want at most the limit", offset, len(page))
+		}
+
+		if body["offset"] != float64(offset) || body["limit"] != float64(25) || body["total"] != float64(len(fixtures["users"])) {
+			t.Errorf("offset %d: got offset %v, limit %v and total %v", offset, body["offset"], body["limit"], body["total"])
+		}
+
+		got = append(got, objectIDs(page)...)
+
+		if body["more"] != true {
+			break
+		}
+
+		offset += len(page)
+	}
+
+	if want := objectIDs(fixtures["users"]); !reflect.DeepEqual(got, want) {
+		t.Errorf("got users %v, want %v", got, want)
+	}
+}
+
+func TestServerLimits(t *testing.T) {
+	server := pagerdutymock.NewTestServer(t, pagerdutymock.GenerateFixtures(1, pagerdutymock.Sizes{Users: 150, Teams: 1, AuditRecords: 1200}))
+
+	tests := map[string]struct {
+		path       string
+		query      url.Values
+		key        string
+		wantStatus int
+		wantCount  int
+	}{
+		"default_limit": {
+			path:       "users",
+			key:        "users",
+			wantStatus: http.StatusOK,
+			wantCount:  pagerdutymock.DefaultLimit,
+		},
+		"max_limit": {
+			path:       "users",
+			query:      url.Values{"limit": {"1000"}},
+			key:        "users",
+			wantStatus: http.StatusOK,
+			wantCount:  pagerdutymock.MaxLimit,
+		},
+		"endpoint_max_limit": {
+			path:       "audit/records",
+			query:      url.Values{"limit": {"5000"}},
+			key:        "records",
+			wantStatus: http.StatusOK,
+			wantCount:  1000,
+		},
+		"invalid_limit": {
+			path:       "users",
+			query:      url.Values{"limit": {"0"}},
+			wantStatus: http.StatusBadRequest,
+		},
+		"invalid_offset": {
+			path:       "users",
+			query:      url.Values{"offset": {"first"}},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			status, _, body := get(t, server, tt.path, tt.query)
+			if status != tt.wantStatus {
+				t.Fatalf("got status %d, want %d: %v", status, tt.wantStatus, body)
+			}
+
+			if tt.key != "" && len(objects(body, tt.key)) != tt.wantCount {
+				t.Errorf("got %d objects, want %d", len(objects(body, tt.key)), tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestServerCursorPaging(t *testing.T) {
+	fixtures := pagerdutymock.DefaultFixtures(1)
+	server := pagerdutymock.NewTestServer(t, fixtures)
+
+	var got []string
+
+	query := url.Values{"limit": {"30"}}
+
+	for page := 0; ; page++ {
+		status, _, body := get(t, server, "audit/records", query)
+		if status != http.StatusOK {
+			t.Fatalf("page %d: got status %d: %v", page, status, body)
+		}
+
+		if _, found := body["more"]; found {
+			t.Errorf("page %d: got more with cursor pagination", page)
+		}
+
+		got = append(got, objectIDs(objects(body, "records"))...)
+
+		nextCursor, _ := body["next_cursor"].(string)
+		if nextCursor == "" {
+			if body["next_cursor"] != nil {
+				t.Errorf("page %d: got next_cursor %v, want null on the last page", page, body["next_cursor"])
+			}
+
+			break
+		}
+
+		query.Set("cursor", nextCursor)
+	}
+
+	if want := objectIDs(fixtures["audit_records"]); !reflect.DeepEqual(got, want) {
+		t.Errorf("got records %v, want %v", got, want)
+	}
+
+	if status, _, _ := get(t, server, "audit/records", url.Values{"cursor": {"not a cursor"}}); status != http.StatusBadRequest {
+		t.Errorf("got status %d for an invalid cursor, want %d", status, http.StatusBadRequest)
+	}
+}
+
+func TestServerInclude(t *testing.T) {
+	fixtures := pagerdutymock.DefaultFixtures(1)
+	server := pagerdutymock.NewTestServer(t, fixtures)
+
+	_, _, body := get(t, server, "users", url.Values{"limit": {"1"}})
+	contactMethod := objects(body, "users")[0].(map[string]any)["contact_methods"].([]any)[0].(map[string]any)
+
+	if contactMethod["type"] != "email_contact_method_reference" || contactMethod["address"] != nil {
+		t.Errorf("got contact method %v, want a reference", contactMethod)
+	}
+
+	_, _, body = get(t, server, "users", url.Values{"limit": {"1"}, "include[]": {"contact_methods", "teams"}})
+	user := objects(body, "users")[0].(map[string]any)
+	contactMethod = user["contact_methods"].([]any)[0].(map[string]any)
+	team := user["teams"].([]any)[0].(map[string]any)
+
+	if contactMethod["type"] != "email_contact_method" || contactMethod["address"] != user["email"] {
+		t.Errorf("got contact method %v, want the contact method with the user's email", contactMethod)
+	}
+
+	if team["type"] != "team" || team["description"] == nil {
+		t.Errorf("got team %v, want the full team", team)
+	}
+
+	// The fixtures are not modified by expansions.
+	if reference := fixtures["users"][0]["contact_methods"].([]any)[0].(map[string]any); reference["address"] != nil {
+		t.Errorf("got fixture contact method %v, want a reference", reference)
+	}
+
+	if status, _, _ := get(t, server, "users", url.Values{"include[]": {"schedules"}}); status != http.StatusBadRequest {
+		t.Errorf("got status %d for an unsupported include, want %d", status, http.StatusBadRequest)
+	}
+}
+
+func TestServerFilters(t *testing.T) {
+	fixtures := pagerdutymock.DefaultFixtures(1)
+	server := pagerdutymock.NewTestServer(t, fixtures)
+
+	// count returns the number of fixtures of the collection that match.
+	count := func(collection string, match func(object map[string]any) bool) int {
+		n := 0
+
+		for _, object := range fixtures[collection] {
+			if match(object) {
+				n++
+			}
+		}
+
+		return n
+	}
+
+	since := pagerdutymock.FixturesEpoch.Add(10 * 24 * time.Hour)
+	until := pagerdutymock.FixturesEpoch.Add(20 * 24 * time.Hour)
+
+	between := func(attribute string) func(object map[string]any) bool {
+		return func(object map[string]any) bool {
+			at, _ := time.Parse(time.RFC3339, object[attribute].(string))
+
+			return !at.Before(since) && at.Before(until)
+		}
+	}
+
+	teamID := fixtures["teams"][0]["id"].(string)
+
+	tests := map[string]struct {
+		path  string
+		key   string
+		query url.Values
+		want  int
+	}{
+		"incidents_since_until": {
+			path:  "incidents",
+			key:   "incidents",
+			query: url.Values{"since": {since.Format(time.RFC3339)}, "until": {until.Format(time.RFC3339)}},
+			want:  count("incidents", between("created_at")),
+		},
+		"audit_records_since": {
+			path:  "audit/records",
+			key:   "records",
+			query: url.Values{"since": {since.Format(time.RFC3339)}, "limit": {"1000"}},
+			want: count("audit_records", func(object map[string]any) bool {
+				at, _ := time.Parse(time.RFC3339, object["execution_time"].(string))
+
+				return !at.Before(since)
+			}),
+		},
+		"incidents_statuses": {
+			path:  "incidents",
+			key:   "incidents",
+			query: url.Values{"statuses[]": {"triggered", "acknowledged"}},
+			want: count("incidents", func(object map[string]any) bool {
+				return object["status"] == "triggered" || object["status"] == "acknowledged"
+			}),
+		},
+		"users_team_ids": {
+			path:  "users",
+			key:   "users",
+			query: url.Values{"team_ids[]": {teamID}},
+			want: count("users", func(object map[string]any) bool {
+				return object["teams"].([]any)[0].(map[string]any)["id"] == teamID
+			}),
+		},
+		"log_entries_is_overview": {
+			path:  "log_entries",
+			key:   "log_entries",
+			query: url.Values{"is_overview": {"true"}, "limit": {"100"}},
+			want: count("log_entries", func(object map[string]any) bool {
+				return object["type"] != "notify_log_entry"
+			}),
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if tt.want == 0 || tt.want > pagerdutymock.MaxLimit {
+				t.Fatalf("the fixtures match %d objects, the test needs a single non-empty page", tt.want)
+			}
+
+			query := url.Values{"limit": {"100"}}
+			for name, values := range tt.query {
+				query[name] = values
+			}
+
+			status, _, body := get(t, server, tt.path, query)
+			if status != http.StatusOK {
+				t.Fatalf("got status %d: %v", status, body)
+			}
+
+			if got := len(objects(body, tt.key)); got != tt.want {
+				t.Errorf("got %d objects, want %d", got, tt.want)
+			}
+		})
+	}
+
+	if status, _, _ := get(t, server, "incidents", url.Values{"since": {"yesterday"}}); status != http.StatusBadRequest {
+		t.Errorf("got status %d for an invalid since, want %d", status, http.StatusBadRequest)
+	}
+}
+
+func TestServerServiceDependencies(t *testing.T) {
+	fixtures := pagerdutymock.DefaultFixtures(1)
+	server := pagerdutymock.NewTestServer(t, fixtures)
+
+	businessServices := fixtures["business_services"]
+	first, last := businessServices[0]["id"].(string), businessServices[len(businessServices)-1]["id"].(string)
+
+	// The last business service depends on the first, whose relationships include it as the
+	// supporting service.
+	_, _, body := get(t, server, "service_dependencies/business_services/"+first, nil)
+
+	found := false
+
+	for _, relationship := range objects(body, "relationships") {
+		relationship := relationship.(map[string]any)
+		supporting := relationship["supporting_service"].(map[string]any)["id"]
+		dependent := relationship["dependent_service"].(map[string]any)["id"]
+
+		if dependent != first && supporting != first {
+			t.Errorf("got relationship %v, which doesn't involve %s", relationship, first)
+		}
+
+		found = found || (supporting == first && dependent == last)
+	}
+
+	if !found {
+		t.Errorf("got relationships %v, want the dependency of %s on %s", objects(body, "relationships"), last, first)
+	}
+
+	if status, _, _ := get(t, server, "service_dependencies/business_services/PUNKNOWN", nil); status != http.StatusNotFound {
+		t.Errorf("got status %d for an unknown business service, want %d", status, http.StatusNotFound)
+	}
+}
+
+func TestServerAuthorization(t *testing.T) {
+	server := pagerdutymock.NewTestServer(t, pagerdutymock.DefaultFixtures(1))
+	server.Token = "secret"
+
+	for authorization, want := range map[string]int{
+		"":                    http.StatusUnauthorized,
+		"Token token=test":    http.StatusUnauthorized,
+		"Token token=secret":  http.StatusOK,
+		"Bearer secret":       http.StatusOK,
+		"Bearer Token secret": http.StatusUnauthorized,
+	} {
+		request, err := http.NewRequest(http.MethodGet, server.URL+"/teams", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if authorization != "" {
+			request.Header.Set("Authorization", authorization)
+		}
+
+		response, err := server.Client.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		response.Body.Close()
+
+		if response.StatusCode != want {
+			t.Errorf("authorization %q: got status %d, want %d", authorization, response.StatusCode, want)
+		}
+	}
+}
+
+func TestServerFaults(t *testing.T) {
+	server := pagerdutymock.NewTestServer(t, pagerdutymock.DefaultFixtures(1))
+
+	server.AddFault(pagerdutymock.Fault{Path: "users", Status: http.StatusTooManyRequests, RetryAfter: 2, Times: 2})
+	server.AddFault(pagerdutymock.Fault{Path: "teams", Status: http.StatusServiceUnavailable})
+
+	for i := 0; i < 2; i++ {
+		status, header, body := get(t, server, "users", nil)
+		if status != http.StatusTooManyRequests || header.Get("Retry-After") != "2" {
+			t.Errorf("request %d: got status %d and Retry-After %q, want 429 and 2", i, status, header.Get("Retry-After"))
+		}
+
+		if apiError, _ := body["error"].(map[string]any); apiError["code"] != float64(2020) {
+			t.Errorf("request %d: got body %v, want a rate limit error", i, body)
+		}
+	}
+
+	// The fault is removed after it applied Times times, and doesn't apply to other paths.
+	if status, _, _ := get(t, server, "users", nil); status != http.StatusOK {
+		t.Errorf("got status %d once the fault expired, want 200", status)
+	}
+
+	if status, _, _ := get(t, server, "vendors", nil); status != http.StatusOK {
+		t.Errorf("got status %d for another path, want 200", status)
+	}
+
+	for i := 0; i < 3; i++ {
+		if status, _, _ := get(t, server, "teams", nil); status != http.StatusServiceUnavailable {
+			t.Errorf("request %d: got status %d, want 503 until the faults are cleared", i, status)
+		}
+	}
+
+	server.ClearFaults()
+
+	if status, _, _ := get(t, server, "teams", nil); status != http.StatusOK {
+		t.Errorf("got status %d once the faults were cleared, want 200", status)
+	}
+
+	// A fault without a status delays the response, which is then served normally.
+	server.AddFault(pagerdutymock.Fault{Delay: 50 * time.Millisecond, Times: 1})
+
+	start := time.Now()
+
+	if status, _, _ := get(t, server, "teams", nil); status != http.StatusOK || time.Since(start) < 50*time.Millisecond {
+		t.Errorf("got status %d after %s, want 200 after the delay", status, time.Since(start))
+	}
+
+	if got := len(server.Requests()); got != 9 {
+		t.Errorf("got %d requests, want 9", got)
+	}
+}
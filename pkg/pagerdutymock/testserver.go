@@ -0,0 +1,53 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pagerdutymock
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestServer is a mock server served over HTTPS for the duration of a test.
+type TestServer struct {
+	*Server
+
+	// URL is the base URL of the server, e.g. https://127.0.0.1:41234.
+	URL string
+
+	// Client is an HTTP client that trusts the certificate of the server. Its Transport can be set
+	// as the transport of the Datasource's client with adapter.WithTransport.
+	Client *http.Client
+
+	// Fixtures are the fixtures served by the server.
+	Fixtures Fixtures
+}
+
+// NewTestServer serves the fixtures, or DefaultFixtures(1) if nil, over HTTPS until the test
+// completes.
+func NewTestServer(t testing.TB, fixtures Fixtures) *TestServer {
+	t.Helper()
+
+	if fixtures == nil {
+		fixtures = DefaultFixtures(1)
+	}
+
+	mock := New(fixtures)
+
+	server := httptest.NewTLSServer(mock)
+	t.Cleanup(server.Close)
+
+	return &TestServer{Server: mock, URL: server.URL, Client: server.Client(), Fixtures: fixtures}
+}
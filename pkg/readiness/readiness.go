@@ -0,0 +1,127 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package readiness periodically runs deep readiness checks and reports their result through
+// the standard gRPC health service.
+package readiness
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Check verifies that a dependency of the adapter is ready.
+type Check func(ctx context.Context) error
+
+// AuthTokensCheck returns a Check that verifies that the file at path contains a non-empty JSON
+// array of adapter authentication tokens, as loaded by the adapter-framework server.
+func AuthTokensCheck(path string) Check {
+	return func(_ context.Context) error {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read auth tokens file: %w", err)
+		}
+
+		var tokens []string
+		if err := json.Unmarshal(content, &tokens); err != nil {
+			return fmt.Errorf("auth tokens file is not a JSON array of strings: %w", err)
+		}
+
+		if len(tokens) == 0 {
+			return errors.New("auth tokens file contains no token")
+		}
+
+		return nil
+	}
+}
+
+// DNSCheck returns a Check that verifies that host can be resolved.
+func DNSCheck(host string) Check {
+	return func(ctx context.Context) error {
+		if _, err := net.DefaultResolver.LookupHost(ctx, host); err != nil {
+			return fmt.Errorf("failed to resolve %s: %w", host, err)
+		}
+
+		return nil
+	}
+}
+
+// Prober runs Checks periodically and sets the status of Services in the Health server to
+// SERVING if all of them pass, and to NOT_SERVING otherwise.
+type Prober struct {
+	// Health is the gRPC health server to report statuses to.
+	Health *health.Server
+
+	// Services are the names of the services whose status reflects the checks.
+	Services []string
+
+	// Checks are the checks to run. If empty, the services are always SERVING.
+	Checks []Check
+
+	// Interval is the interval between two runs of the checks.
+	Interval time.Duration
+
+	// Timeout is the maximum duration of a run of the checks.
+	Timeout time.Duration
+
+	// OnError is called with the error of each failed check, if set.
+	OnError func(error)
+}
+
+// Run runs the checks immediately, then every Interval until stop is closed.
+func (p *Prober) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+
+	for {
+		p.probe()
+
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (p *Prober) probe() {
+	ctx, cancel := context.WithTimeout(context.Background(), p.Timeout)
+	defer cancel()
+
+	status := healthpb.HealthCheckResponse_SERVING
+
+	for _, check := range p.Checks {
+		if err := check(ctx); err != nil {
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+
+			if p.OnError != nil {
+				p.OnError(err)
+			}
+		}
+	}
+
+	// Once the health server is shut down, status updates are ignored, so services are not
+	// reported as SERVING again while the adapter drains requests.
+	for _, service := range p.Services {
+		p.Health.SetServingStatus(service, status)
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tksarunachalam/sgnl-adapter/pkg/adapter"
+)
+
+// DefaultPages is the default number of pages sampled per entity.
+const DefaultPages = 2
+
+// Options configure the discovery.
+type Options struct {
+	// BaseURL is the base URL of the datasource, e.g. https://api.pagerduty.com.
+	BaseURL string
+
+	// Token is the Authorization header of the requests to the datasource.
+	Token string
+
+	// Entities are the external IDs of the entities to discover.
+	// Defaults to every entity of adapter.ValidEntityExternalIDs.
+	Entities []string
+
+	// Pages is the maximum number of pages sampled per entity.
+	// Defaults to DefaultPages.
+	Pages int
+
+	// PageSize is the size of the sampled pages, and the page size of the entity configurations.
+	// Defaults to the maximum page size of each entity.
+	PageSize int64
+
+	// MaxDepth is the depth of the nested objects whose fields are inferred, see Inferrer.
+	MaxDepth int
+}
+
+// Discover samples pages of each entity with the client and returns a template of their inferred
+// entity configurations. If an entity can't be sampled, it is left out of the template and its
+// error is returned, joined with the errors of other entities.
+func Discover(ctx context.Context, client adapter.Client, opts Options) (*Template, error) {
+	entities := opts.Entities
+	if len(entities) == 0 {
+		for entityExternalID := range adapter.ValidEntityExternalIDs {
+			entities = append(entities, entityExternalID)
+		}
+
+		sort.Strings(entities)
+	}
+
+	if opts.Pages <= 0 {
+		opts.Pages = DefaultPages
+	}
+
+	template := &Template{Entities: make(map[string]EntityConfig, len(entities))}
+
+	var errs []error
+
+	jsonPaths := false
+
+	for _, entityExternalID := range entities {
+		entity, found := adapter.ValidEntityExternalIDs[entityExternalID]
+		if !found {
+			errs = append(errs, fmt.Errorf("unknown entity %q", entityExternalID))
+
+			continue
+		}
+
+		inferrer := &Inferrer{MaxDepth: opts.MaxDepth}
+
+		pageSize := opts.PageSize
+		if pageSize <= 0 {
+			pageSize = entity.MaxPageSize()
+		}
+
+		err := Sample(ctx, client, &adapter.Request{
+			BaseURL:          opts.BaseURL,
+			Token:            opts.Token,
+			PageSize:         pageSize,
+			EntityExternalID: entityExternalID,
+		}, opts.Pages, inferrer.Observe)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to sample %s: %w", entityExternalID, err))
+
+			continue
+		}
+
+		config := NewEntityConfig(entityExternalID, entity.UniqueIDAttrExternalID(), inferrer, pageSize)
+
+		for _, attribute := range config.Attributes {
+			jsonPaths = jsonPaths || strings.HasPrefix(attribute.ExternalID, "$")
+		}
+
+		template.Entities[EntityName(entityExternalID)] = config
+	}
+
+	// Attributes of nested objects are only mapped with JSONPath attribute names enabled.
+	if jsonPaths {
+		config, err := json.Marshal(adapter.Config{EnableJSONPathAttributeNames: true})
+		if err != nil {
+			return nil, err
+		}
+
+		template.AdapterConfig = string(config)
+	}
+
+	return template, errors.Join(errs...)
+}
+
+// Sample requests up to pages pages of the entity with the client, following cursors from the
+// request's, and calls observe with each object.
+func Sample(ctx context.Context, client adapter.Client, request *adapter.Request, pages int, observe func(map[string]any)) error {
+	for page := 0; page < pages; page++ {
+		response, err := client.GetPage(ctx, request)
+		if err != nil {
+			return fmt.Errorf("page %d: %s: %s", page, err.Code, err.Message)
+		}
+
+		for _, object := range response.Objects {
+			observe(object)
+		}
+
+		if response.NextCursor == "" {
+			return nil
+		}
+
+		request.Cursor = response.NextCursor
+	}
+
+	return nil
+}
@@ -0,0 +1,234 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package schema discovers the attributes of the adapter's entities from sampled PagerDuty objects,
+// and emits them as SGNL entity configurations, see Discover.
+package schema
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+	"github.com/sgnl-ai/adapter-framework/web"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/adapter"
+)
+
+// DefaultMaxDepth is the default depth of the nested objects whose fields are inferred.
+const DefaultMaxDepth = 2
+
+// Attribute is an inferred attribute of an entity.
+type Attribute struct {
+	// ExternalID is the name of a top-level field, or a JSONPath expression for the fields of
+	// nested objects and arrays of objects, e.g. $.service.id or $.teams[*].id.
+	ExternalID string
+
+	// Type is the type of the attribute's values.
+	Type framework.AttributeType
+
+	// List is true if the attribute's values are in an array.
+	List bool
+
+	// Count is the number of sampled objects in which the attribute has a value.
+	Count int
+}
+
+// kind is a bit set of the kinds of JSON values observed for an attribute.
+type kind uint8
+
+const (
+	kindBool kind = 1 << iota
+	kindInt
+	kindDouble
+	kindDateTime
+	kindString
+)
+
+type observation struct {
+	kinds kind
+	list  bool
+	count int
+
+	// object is the index of the last object the attribute was observed in, to count each
+	// object once.
+	object int
+}
+
+// fieldNamePattern matches the field names that can be used in JSONPath expressions with the
+// dot notation. The fields of nested objects with other names are ignored.
+var fieldNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Inferrer infers the attributes of an entity from the objects it observes.
+type Inferrer struct {
+	// DateTimeFormats are the formats of datetime strings.
+	// Defaults to adapter.DateTimeFormats.
+	DateTimeFormats []web.DateTimeFormatWithTimeZone
+
+	// MaxDepth is the depth of the nested objects whose fields are inferred as JSONPath attributes.
+	// If 0, only top-level fields are inferred.
+	MaxDepth int
+
+	objects      int
+	observations map[string]*observation
+}
+
+// Observe infers the attributes of a JSON object, as unmarshalled by the Datasource.
+func (i *Inferrer) Observe(object map[string]any) {
+	if i.observations == nil {
+		i.observations = make(map[string]*observation)
+	}
+
+	i.objects++
+
+	for key, value := range object {
+		i.walk(key, "$."+key, value, 0, false)
+	}
+}
+
+// Objects returns the number of observed objects.
+func (i *Inferrer) Objects() int {
+	return i.objects
+}
+
+// walk infers the attributes of a value. id is the external ID of the attribute if the value is a
+// scalar or an array of scalars, and path is the JSONPath expression of the value.
+func (i *Inferrer) walk(id, path string, value any, depth int, list bool) {
+	switch v := value.(type) {
+	case nil:
+		return
+	case map[string]any:
+		if depth >= i.MaxDepth {
+			return
+		}
+
+		for key, child := range v {
+			if fieldNamePattern.MatchString(key) {
+				i.walk(path+"."+key, path+"."+key, child, depth+1, list)
+			}
+		}
+	case []any:
+		for _, item := range v {
+			if _, isObject := item.(map[string]any); isObject {
+				i.walk(path+"[*]", path+"[*]", item, depth, true)
+			} else {
+				i.walk(id, path, item, depth, true)
+			}
+		}
+	default:
+		i.observe(id, i.kindOf(v), list)
+	}
+}
+
+func (i *Inferrer) observe(id string, k kind, list bool) {
+	o, found := i.observations[id]
+	if !found {
+		o = &observation{}
+		i.observations[id] = o
+	}
+
+	o.kinds |= k
+	o.list = o.list || list
+
+	if o.object != i.objects {
+		o.object = i.objects
+		o.count++
+	}
+}
+
+// kindOf returns the kind of a scalar JSON value, or 0 for an empty string, which tells nothing
+// about the attribute's type.
+func (i *Inferrer) kindOf(value any) kind {
+	switch v := value.(type) {
+	case bool:
+		return kindBool
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return kindInt
+		}
+
+		return kindDouble
+	case string:
+		switch {
+		case v == "":
+			return 0
+		case i.isDateTime(v):
+			return kindDateTime
+		default:
+			return kindString
+		}
+	default:
+		return kindString
+	}
+}
+
+func (i *Inferrer) isDateTime(value string) bool {
+	formats := i.DateTimeFormats
+	if formats == nil {
+		formats = adapter.DateTimeFormats
+	}
+
+	for _, format := range formats {
+		if _, err := time.Parse(format.Format, value); err == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Attributes returns the inferred attributes: top-level fields sorted by name, then JSONPath
+// attributes sorted by expression.
+// Attributes whose values have several kinds are strings, except integers and doubles which are
+// doubles. Attributes without any value but null or empty strings are strings.
+func (i *Inferrer) Attributes() []Attribute {
+	attributes := make([]Attribute, 0, len(i.observations))
+
+	for id, o := range i.observations {
+		attributes = append(attributes, Attribute{
+			ExternalID: id,
+			Type:       attributeType(o.kinds),
+			List:       o.list,
+			Count:      o.count,
+		})
+	}
+
+	sort.Slice(attributes, func(a, b int) bool {
+		aPath, bPath := strings.HasPrefix(attributes[a].ExternalID, "$"), strings.HasPrefix(attributes[b].ExternalID, "$")
+		if aPath != bPath {
+			return bPath
+		}
+
+		return attributes[a].ExternalID < attributes[b].ExternalID
+	})
+
+	return attributes
+}
+
+func attributeType(kinds kind) framework.AttributeType {
+	switch kinds {
+	case kindBool:
+		return framework.AttributeTypeBool
+	case kindInt:
+		return framework.AttributeTypeInt64
+	case kindDouble, kindInt | kindDouble:
+		return framework.AttributeTypeDouble
+	case kindDateTime:
+		return framework.AttributeTypeDateTime
+	default:
+		return framework.AttributeTypeString
+	}
+}
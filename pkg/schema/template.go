@@ -0,0 +1,235 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/adapter"
+	"gopkg.in/yaml.v3"
+)
+
+// Template is the entities section of a SGNL system of record template, with the adapter config
+// the attributes require.
+type Template struct {
+	// AdapterConfig is the JSON adapter config required by the attributes, if any.
+	AdapterConfig string `json:"adapterConfig,omitempty" yaml:"adapterConfig,omitempty"`
+
+	// Entities are the configurations of the entities, by entity name.
+	Entities map[string]EntityConfig `json:"entities" yaml:"entities"`
+}
+
+// EntityConfig is the configuration of an entity in a SGNL system of record template.
+type EntityConfig struct {
+	DisplayName      string            `json:"displayName" yaml:"displayName"`
+	ExternalID       string            `json:"externalId" yaml:"externalId"`
+	Description      string            `json:"description,omitempty" yaml:"description,omitempty"`
+	PageSize         int64             `json:"pageSize" yaml:"pageSize"`
+	PagesOrderedByID bool              `json:"pagesOrderedById" yaml:"pagesOrderedById"`
+	Attributes       []AttributeConfig `json:"attributes" yaml:"attributes"`
+}
+
+// AttributeConfig is the configuration of an attribute in a SGNL system of record template.
+type AttributeConfig struct {
+	Name       string `json:"name" yaml:"name"`
+	ExternalID string `json:"externalId" yaml:"externalId"`
+	Type       string `json:"type" yaml:"type"`
+	Indexed    bool   `json:"indexed" yaml:"indexed"`
+	UniqueID   bool   `json:"uniqueId" yaml:"uniqueId"`
+	List       bool   `json:"list" yaml:"list"`
+}
+
+// typeNames are the names of the attribute types in SGNL templates.
+var typeNames = map[framework.AttributeType]string{
+	framework.AttributeTypeBool:     "Bool",
+	framework.AttributeTypeDateTime: "DateTime",
+	framework.AttributeTypeDouble:   "Double",
+	framework.AttributeTypeDuration: "Duration",
+	framework.AttributeTypeInt64:    "Int64",
+	framework.AttributeTypeString:   "String",
+}
+
+// typesByName are the attribute types by their names in SGNL templates.
+var typesByName = func() map[string]framework.AttributeType {
+	types := make(map[string]framework.AttributeType, len(typeNames))
+	for attributeType, name := range typeNames {
+		types[name] = attributeType
+	}
+
+	return types
+}()
+
+var wordSeparatorPattern = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// NewEntityConfig returns the configuration of the entity with the attributes inferred by the
+// inferrer. The unique ID attribute is always first, indexed, and a string, since it may be
+// synthesized by the adapter and missing from the sampled objects.
+// Pages are never ordered by ID, since PagerDuty can't sort objects by ID and SGNL expects every
+// page to follow the previous one.
+func NewEntityConfig(entityExternalID, uniqueIDAttribute string, inferrer *Inferrer, pageSize int64) EntityConfig {
+	config := EntityConfig{
+		DisplayName: displayName(entityExternalID),
+		ExternalID:  entityExternalID,
+		Description: fmt.Sprintf("Inferred from %d sampled objects.", inferrer.Objects()),
+		PageSize:    pageSize,
+		Attributes: []AttributeConfig{{
+			Name:       attributeName(uniqueIDAttribute),
+			ExternalID: uniqueIDAttribute,
+			Type:       typeNames[framework.AttributeTypeString],
+			Indexed:    true,
+			UniqueID:   true,
+		}},
+	}
+
+	names := map[string]bool{config.Attributes[0].Name: true}
+
+	for _, attribute := range inferrer.Attributes() {
+		if attribute.ExternalID == uniqueIDAttribute || attribute.ExternalID == "$."+uniqueIDAttribute {
+			continue
+		}
+
+		// Attribute names must be unique, e.g. for created_at and $.createdAt.
+		name := attributeName(attribute.ExternalID)
+		for suffix := 2; names[name]; suffix++ {
+			name = fmt.Sprintf("%s%d", attributeName(attribute.ExternalID), suffix)
+		}
+
+		names[name] = true
+
+		config.Attributes = append(config.Attributes, AttributeConfig{
+			Name:       name,
+			ExternalID: attribute.ExternalID,
+			Type:       typeNames[attribute.Type],
+			List:       attribute.List,
+		})
+	}
+
+	return config
+}
+
+// EntityName returns the name of the entity in templates: its singular external ID in CamelCase,
+// e.g. BusinessService for business_services.
+func EntityName(entityExternalID string) string {
+	return strings.Join(entityWords(entityExternalID), "")
+}
+
+// displayName returns the display name of the entity, e.g. Business Service for business_services.
+func displayName(entityExternalID string) string {
+	return strings.Join(entityWords(entityExternalID), " ")
+}
+
+// entityWords returns the capitalized words of the entity's singular external ID.
+func entityWords(entityExternalID string) []string {
+	words := words(entityExternalID)
+
+	if last := len(words) - 1; last >= 0 {
+		words[last] = singular(words[last])
+	}
+
+	for i, word := range words {
+		words[i] = capitalize(word)
+	}
+
+	return words
+}
+
+// attributeName returns the name of an attribute in templates: its external ID in camelCase,
+// e.g. teamsId for $.teams[*].id.
+func attributeName(externalID string) string {
+	words := words(externalID)
+
+	for i := 1; i < len(words); i++ {
+		words[i] = capitalize(words[i])
+	}
+
+	return strings.Join(words, "")
+}
+
+func words(s string) []string {
+	return strings.Fields(wordSeparatorPattern.ReplaceAllString(s, " "))
+}
+
+func singular(word string) string {
+	switch {
+	case strings.HasSuffix(word, "ies"):
+		return strings.TrimSuffix(word, "ies") + "y"
+	case strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss"):
+		return strings.TrimSuffix(word, "s")
+	default:
+		return word
+	}
+}
+
+func capitalize(word string) string {
+	if word == "" {
+		return word
+	}
+
+	return strings.ToUpper(word[:1]) + word[1:]
+}
+
+// ReadTemplate reads a template, as YAML or JSON.
+func ReadTemplate(r io.Reader) (*Template, error) {
+	var template Template
+
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+
+	if err := decoder.Decode(&template); err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	return &template, nil
+}
+
+// Config returns the adapter config of the template, or an empty config if it has none.
+func (t *Template) Config() (*adapter.Config, error) {
+	config := &adapter.Config{}
+
+	if t.AdapterConfig == "" {
+		return config, nil
+	}
+
+	if err := json.Unmarshal([]byte(t.AdapterConfig), config); err != nil {
+		return nil, fmt.Errorf("failed to parse adapterConfig: %w", err)
+	}
+
+	return config, nil
+}
+
+// FrameworkAttributes returns the attributes of the entity as requested to the adapter.
+func (c *EntityConfig) FrameworkAttributes() ([]*framework.AttributeConfig, error) {
+	attributes := make([]*framework.AttributeConfig, 0, len(c.Attributes))
+
+	for _, attribute := range c.Attributes {
+		attributeType, found := typesByName[attribute.Type]
+		if !found {
+			return nil, fmt.Errorf("attribute %s of entity %s has an unknown type %q", attribute.Name, c.ExternalID, attribute.Type)
+		}
+
+		attributes = append(attributes, &framework.AttributeConfig{
+			ExternalId: attribute.ExternalID,
+			Type:       attributeType,
+			List:       attribute.List,
+		})
+	}
+
+	return attributes, nil
+}
@@ -0,0 +1,365 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package serverconfig loads the configuration of the adapter server from flags, environment
+// variables and an optional YAML or JSON file.
+package serverconfig
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"time"
+
+	"github.com/tksarunachalam/sgnl-adapter/pkg/adapter"
+)
+
+// Config is the configuration of the adapter server.
+type Config struct {
+	// ListenAddress is the address at which the gRPC server listens, e.g. ":8080".
+	ListenAddress string `yaml:"listenAddress" json:"listenAddress"`
+
+	// Timeouts are the timeouts of the server and of the requests to the datasource.
+	Timeouts Timeouts `yaml:"timeouts" json:"timeouts"`
+
+	// Retry is the policy to retry requests to the datasource that failed with a transient error.
+	Retry Retry `yaml:"retry" json:"retry"`
+
+	// RateLimit limits the rate of requests to the datasource.
+	RateLimit RateLimit `yaml:"rateLimit" json:"rateLimit"`
+
+	// TLS is the TLS configuration of the gRPC server.
+	TLS TLS `yaml:"tls" json:"tls"`
+
+	// Logging is the logging configuration.
+	Logging Logging `yaml:"logging" json:"logging"`
+
+	// Metrics is the configuration of the Prometheus metrics listener.
+	Metrics Metrics `yaml:"metrics" json:"metrics"`
+
+	// Readiness is the configuration of the readiness checks.
+	Readiness Readiness `yaml:"readiness" json:"readiness"`
+
+	// Adapters are the adapter types registered on the server.
+	Adapters []AdapterType `yaml:"adapters" json:"adapters"`
+}
+
+// Timeouts are the timeouts of the server and of the requests to the datasource.
+type Timeouts struct {
+	// HTTPClient is the overall timeout of the HTTP client used to query the datasource.
+	HTTPClient time.Duration `yaml:"httpClient" json:"httpClient"`
+
+	// Request is the timeout of each HTTP request to the datasource.
+	Request time.Duration `yaml:"request" json:"request"`
+
+	// Shutdown is the maximum duration to drain in-flight requests on shutdown.
+	Shutdown time.Duration `yaml:"shutdown" json:"shutdown"`
+}
+
+// Retry is the policy to retry requests to the datasource that failed with a transient error.
+type Retry struct {
+	// MaxAttempts is the maximum number of attempts of a request. 1 disables retries.
+	MaxAttempts int `yaml:"maxAttempts" json:"maxAttempts"`
+
+	// InitialBackoff is the delay before the first retry, doubled after each retry.
+	InitialBackoff time.Duration `yaml:"initialBackoff" json:"initialBackoff"`
+
+	// MaxBackoff is the maximum delay before a retry.
+	MaxBackoff time.Duration `yaml:"maxBackoff" json:"maxBackoff"`
+}
+
+// RateLimit limits the rate of requests to the datasource.
+type RateLimit struct {
+	// RequestsPerSecond is the average number of requests per second. 0 disables rate limiting.
+	RequestsPerSecond float64 `yaml:"requestsPerSecond" json:"requestsPerSecond"`
+
+	// Burst is the maximum number of requests sent at once.
+	Burst int `yaml:"burst" json:"burst"`
+}
+
+// TLS is the TLS configuration of the gRPC server.
+type TLS struct {
+	// CertFile is the path of the PEM encoded server certificate. TLS is disabled if empty.
+	CertFile string `yaml:"certFile" json:"certFile"`
+
+	// KeyFile is the path of the PEM encoded server private key.
+	KeyFile string `yaml:"keyFile" json:"keyFile"`
+
+	// ClientCAFile is the path of the PEM encoded client CA bundle. If set, mutual TLS is enforced.
+	ClientCAFile string `yaml:"clientCAFile" json:"clientCAFile"`
+
+	// ReloadInterval is the interval at which the TLS files are checked for changes.
+	ReloadInterval time.Duration `yaml:"reloadInterval" json:"reloadInterval"`
+}
+
+// Logging is the logging configuration.
+type Logging struct {
+	// Level is the minimum level of the logs: debug, info, warn or error.
+	Level string `yaml:"level" json:"level"`
+
+	// Format is the format of the logs: json or text.
+	Format string `yaml:"format" json:"format"`
+}
+
+// Metrics is the configuration of the Prometheus metrics listener.
+type Metrics struct {
+	// Port is the port at which metrics are exposed at /metrics. 0 disables the listener.
+	Port int `yaml:"port" json:"port"`
+}
+
+// Readiness is the configuration of the readiness checks.
+type Readiness struct {
+	// Deep enables the checks of the auth tokens file and of outbound DNS resolution.
+	Deep bool `yaml:"deep" json:"deep"`
+
+	// DNSHost is the host resolved by the deep readiness check.
+	DNSHost string `yaml:"dnsHost" json:"dnsHost"`
+
+	// Interval is the interval between two runs of the readiness checks.
+	Interval time.Duration `yaml:"interval" json:"interval"`
+}
+
+// AdapterType is an adapter type registered on the server, e.g. "PagerDuty-1.0.0".
+// Several types can be registered side by side, for instance to roll out a new version.
+type AdapterType struct {
+	// Type is the adapter type, which must match the type of the Adapter object configured in SGNL.
+	Type string `yaml:"type" json:"type"`
+
+	// Entities are the external IDs of the entities served by the adapter type.
+	// If empty, every entity is served.
+	Entities []string `yaml:"entities" json:"entities"`
+
+	// Config is the default adapter config of the adapter type, in the same format as the config of
+	// the Adapter object in SGNL, e.g. {"apiVersion": "v1"}. Fields set in the config of a request
+	// take precedence.
+	Config map[string]any `yaml:"config" json:"config"`
+}
+
+// AdapterConfig returns the default config of the adapter type, or nil if it has none.
+// Unknown fields are rejected.
+func (t *AdapterType) AdapterConfig() (*adapter.Config, error) {
+	if len(t.Config) == 0 {
+		return nil, nil
+	}
+
+	// The config is converted through JSON to honor the json tags of adapter.Config.
+	raw, err := json.Marshal(t.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+
+	var config adapter.Config
+	if err := decoder.Decode(&config); err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+// Default returns the default configuration.
+func Default() *Config {
+	return &Config{
+		ListenAddress: ":8080",
+		Timeouts: Timeouts{
+			HTTPClient: 30 * time.Second,
+			Request:    5 * time.Second,
+			Shutdown:   30 * time.Second,
+		},
+		Retry: Retry{
+			MaxAttempts:    1,
+			InitialBackoff: 500 * time.Millisecond,
+			MaxBackoff:     5 * time.Second,
+		},
+		RateLimit: RateLimit{
+			Burst: 1,
+		},
+		TLS: TLS{
+			ReloadInterval: time.Minute,
+		},
+		Logging: Logging{
+			Level:  "info",
+			Format: "json",
+		},
+		Readiness: Readiness{
+			DNSHost:  "api.pagerduty.com",
+			Interval: 30 * time.Second,
+		},
+		Adapters: []AdapterType{
+			{Type: "Test-1.0.0"},
+		},
+	}
+}
+
+// Validate returns an error listing every invalid field of the configuration.
+func (c *Config) Validate() error {
+	var errs []error
+
+	invalid := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if _, _, err := net.SplitHostPort(c.ListenAddress); err != nil {
+		invalid("listenAddress %q must be a host:port address: %v", c.ListenAddress, err)
+	}
+
+	// The HTTP client timeout is set in whole seconds.
+	if c.Timeouts.HTTPClient < time.Second {
+		invalid("timeouts.httpClient must be at least 1s")
+	}
+
+	if c.Timeouts.Request <= 0 {
+		invalid("timeouts.request must be positive")
+	}
+
+	if c.Timeouts.Shutdown < 0 {
+		invalid("timeouts.shutdown must not be negative")
+	}
+
+	if c.Retry.MaxAttempts < 1 {
+		invalid("retry.maxAttempts must be at least 1")
+	}
+
+	if c.Retry.MaxAttempts > 1 && (c.Retry.InitialBackoff <= 0 || c.Retry.MaxBackoff < c.Retry.InitialBackoff) {
+		invalid("retry.initialBackoff must be positive and not greater than retry.maxBackoff")
+	}
+
+	if c.RateLimit.RequestsPerSecond < 0 {
+		invalid("rateLimit.requestsPerSecond must not be negative")
+	}
+
+	if c.RateLimit.RequestsPerSecond > 0 && c.RateLimit.Burst < 1 {
+		invalid("rateLimit.burst must be at least 1")
+	}
+
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		invalid("tls.certFile and tls.keyFile must be set together")
+	}
+
+	if c.TLS.ClientCAFile != "" && c.TLS.CertFile == "" {
+		invalid("tls.clientCAFile requires tls.certFile and tls.keyFile")
+	}
+
+	if c.TLS.CertFile != "" && c.TLS.ReloadInterval <= 0 {
+		invalid("tls.reloadInterval must be positive")
+	}
+
+	if _, err := c.Logging.SlogLevel(); err != nil {
+		invalid("logging.level %q is invalid: must be debug, info, warn or error", c.Logging.Level)
+	}
+
+	if c.Logging.Format != "json" && c.Logging.Format != "text" {
+		invalid("logging.format %q is invalid: must be json or text", c.Logging.Format)
+	}
+
+	if c.Metrics.Port < 0 || c.Metrics.Port > 65535 {
+		invalid("metrics.port %d is not a valid port", c.Metrics.Port)
+	}
+
+	if c.Readiness.Interval <= 0 {
+		invalid("readiness.interval must be positive")
+	}
+
+	if c.Readiness.Deep && c.Readiness.DNSHost == "" {
+		invalid("readiness.dnsHost is required by deep readiness checks")
+	}
+
+	if len(c.Adapters) == 0 {
+		invalid("adapters must contain at least one adapter type")
+	}
+
+	types := make(map[string]struct{}, len(c.Adapters))
+
+	for i, adapterType := range c.Adapters {
+		if adapterType.Type == "" {
+			invalid("adapters[%d].type is required", i)
+		} else if _, found := types[adapterType.Type]; found {
+			invalid("adapters[%d].type %q is registered more than once", i, adapterType.Type)
+		}
+
+		types[adapterType.Type] = struct{}{}
+
+		for _, entity := range adapterType.Entities {
+			if _, found := adapter.ValidEntityExternalIDs[entity]; !found {
+				invalid("adapters[%d].entities contains unknown entity %q", i, entity)
+			}
+		}
+
+		config, err := adapterType.AdapterConfig()
+		if err == nil && config != nil {
+			err = config.Validate(context.Background())
+		}
+
+		if err != nil {
+			invalid("adapters[%d].config is invalid: %v", i, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// SlogLevel returns the logging level as a slog.Level.
+func (l Logging) SlogLevel() (slog.Level, error) {
+	var level slog.Level
+	err := level.UnmarshalText([]byte(l.Level))
+
+	return level, err
+}
+
+// LogValue implements slog.LogValuer to log the effective configuration.
+// Values of fields whose name denotes a secret are masked by the logger, see logging.Redact.
+func (c *Config) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("listenAddress", c.ListenAddress),
+		slog.Group("timeouts",
+			slog.String("httpClient", c.Timeouts.HTTPClient.String()),
+			slog.String("request", c.Timeouts.Request.String()),
+			slog.String("shutdown", c.Timeouts.Shutdown.String()),
+		),
+		slog.Group("retry",
+			slog.Int("maxAttempts", c.Retry.MaxAttempts),
+			slog.String("initialBackoff", c.Retry.InitialBackoff.String()),
+			slog.String("maxBackoff", c.Retry.MaxBackoff.String()),
+		),
+		slog.Group("rateLimit",
+			slog.Float64("requestsPerSecond", c.RateLimit.RequestsPerSecond),
+			slog.Int("burst", c.RateLimit.Burst),
+		),
+		slog.Group("tls",
+			slog.String("certFile", c.TLS.CertFile),
+			slog.String("keyFile", c.TLS.KeyFile),
+			slog.String("clientCAFile", c.TLS.ClientCAFile),
+			slog.String("reloadInterval", c.TLS.ReloadInterval.String()),
+		),
+		slog.Group("logging",
+			slog.String("level", c.Logging.Level),
+			slog.String("format", c.Logging.Format),
+		),
+		slog.Group("metrics",
+			slog.Int("port", c.Metrics.Port),
+		),
+		slog.Group("readiness",
+			slog.Bool("deep", c.Readiness.Deep),
+			slog.String("dnsHost", c.Readiness.DNSHost),
+			slog.String("interval", c.Readiness.Interval.String()),
+		),
+		slog.Any("adapters", c.Adapters),
+	)
+}
@@ -0,0 +1,298 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serverconfig
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileEnv is the environment variable that sets the path of the configuration file, unless
+// set by the -config flag.
+const ConfigFileEnv = "ADAPTER_CONFIG"
+
+// setting is a configuration field that can be set by a flag and an environment variable.
+type setting struct {
+	flag   string
+	env    string
+	usage  string
+	isBool bool
+	set    func(c *Config, value string) error
+}
+
+func stringSetting(flagName, env, usage string, field func(c *Config) *string) setting {
+	return setting{flag: flagName, env: env, usage: usage, set: func(c *Config, value string) error {
+		*field(c) = value
+
+		return nil
+	}}
+}
+
+func intSetting(flagName, env, usage string, field func(c *Config) *int) setting {
+	return setting{flag: flagName, env: env, usage: usage, set: func(c *Config, value string) error {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+
+		*field(c) = parsed
+
+		return nil
+	}}
+}
+
+func floatSetting(flagName, env, usage string, field func(c *Config) *float64) setting {
+	return setting{flag: flagName, env: env, usage: usage, set: func(c *Config, value string) error {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+
+		*field(c) = parsed
+
+		return nil
+	}}
+}
+
+func boolSetting(flagName, env, usage string, field func(c *Config) *bool) setting {
+	return setting{flag: flagName, env: env, usage: usage, isBool: true, set: func(c *Config, value string) error {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+
+		*field(c) = parsed
+
+		return nil
+	}}
+}
+
+func durationSetting(flagName, env, usage string, field func(c *Config) *time.Duration) setting {
+	return setting{flag: flagName, env: env, usage: usage, set: func(c *Config, value string) error {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+
+		*field(c) = parsed
+
+		return nil
+	}}
+}
+
+// settings are the configuration fields that can be set by flags and environment variables.
+var settings = []setting{
+	stringSetting("listen-address", "ADAPTER_LISTEN_ADDRESS", "The address at which the gRPC server listens",
+		func(c *Config) *string { return &c.ListenAddress }),
+	// The port and timeout flags are kept for backward compatibility.
+	{
+		flag: "port", env: "ADAPTER_PORT", usage: "The server port. Overrides the port of the listen address",
+		set: func(c *Config, value string) error {
+			port, err := strconv.Atoi(value)
+			if err != nil {
+				return err
+			}
+
+			c.ListenAddress = fmt.Sprintf(":%d", port)
+
+			return nil
+		},
+	},
+	{
+		flag: "timeout", env: "ADAPTER_TIMEOUT", usage: "The timeout for the HTTP client used to make requests to the datasource (seconds)",
+		set: func(c *Config, value string) error {
+			seconds, err := strconv.Atoi(value)
+			if err != nil {
+				return err
+			}
+
+			c.Timeouts.HTTPClient = time.Duration(seconds) * time.Second
+
+			return nil
+		},
+	},
+	durationSetting("http-client-timeout", "ADAPTER_HTTP_CLIENT_TIMEOUT", "The timeout for the HTTP client used to make requests to the datasource",
+		func(c *Config) *time.Duration { return &c.Timeouts.HTTPClient }),
+	durationSetting("request-timeout", "ADAPTER_REQUEST_TIMEOUT", "The timeout of each HTTP request to the datasource",
+		func(c *Config) *time.Duration { return &c.Timeouts.Request }),
+	durationSetting("shutdown-timeout", "ADAPTER_SHUTDOWN_TIMEOUT", "The maximum duration to drain in-flight requests on shutdown",
+		func(c *Config) *time.Duration { return &c.Timeouts.Shutdown }),
+	intSetting("retry-max-attempts", "ADAPTER_RETRY_MAX_ATTEMPTS", "The maximum number of attempts of a request to the datasource. 1 disables retries",
+		func(c *Config) *int { return &c.Retry.MaxAttempts }),
+	durationSetting("retry-initial-backoff", "ADAPTER_RETRY_INITIAL_BACKOFF", "The delay before the first retry, doubled after each retry",
+		func(c *Config) *time.Duration { return &c.Retry.InitialBackoff }),
+	durationSetting("retry-max-backoff", "ADAPTER_RETRY_MAX_BACKOFF", "The maximum delay before a retry",
+		func(c *Config) *time.Duration { return &c.Retry.MaxBackoff }),
+	floatSetting("rate-limit", "ADAPTER_RATE_LIMIT", "The maximum average number of requests per second to the datasource. 0 disables rate limiting",
+		func(c *Config) *float64 { return &c.RateLimit.RequestsPerSecond }),
+	intSetting("rate-limit-burst", "ADAPTER_RATE_LIMIT_BURST", "The maximum number of requests sent at once to the datasource",
+		func(c *Config) *int { return &c.RateLimit.Burst }),
+	stringSetting("tls-cert", "ADAPTER_TLS_CERT", "The path of the PEM encoded server certificate. If not set, TLS is disabled",
+		func(c *Config) *string { return &c.TLS.CertFile }),
+	stringSetting("tls-key", "ADAPTER_TLS_KEY", "The path of the PEM encoded server private key",
+		func(c *Config) *string { return &c.TLS.KeyFile }),
+	stringSetting("tls-client-ca", "ADAPTER_TLS_CLIENT_CA", "The path of the PEM encoded client CA bundle. If set, mutual TLS is enforced",
+		func(c *Config) *string { return &c.TLS.ClientCAFile }),
+	durationSetting("tls-reload-interval", "ADAPTER_TLS_RELOAD_INTERVAL", "The interval at which TLS files are checked for changes and reloaded",
+		func(c *Config) *time.Duration { return &c.TLS.ReloadInterval }),
+	stringSetting("log-level", "ADAPTER_LOG_LEVEL", "The minimum level of the logs: debug, info, warn or error",
+		func(c *Config) *string { return &c.Logging.Level }),
+	stringSetting("log-format", "ADAPTER_LOG_FORMAT", "The format of the logs: json or text",
+		func(c *Config) *string { return &c.Logging.Format }),
+	intSetting("metrics-port", "ADAPTER_METRICS_PORT", "The port at which Prometheus metrics are exposed at /metrics. Disabled if 0",
+		func(c *Config) *int { return &c.Metrics.Port }),
+	boolSetting("readiness-deep", "ADAPTER_READINESS_DEEP", "Check that the auth tokens file is loaded and outbound DNS resolution works to report readiness",
+		func(c *Config) *bool { return &c.Readiness.Deep }),
+	stringSetting("readiness-dns-host", "ADAPTER_READINESS_DNS_HOST", "The host resolved by the deep readiness check",
+		func(c *Config) *string { return &c.Readiness.DNSHost }),
+	{
+		flag: "adapter-types", env: "ADAPTER_TYPES",
+		usage: "The comma-separated adapter types to register. Types defined in the configuration file keep their entities and config, others serve every entity",
+		set: func(c *Config, value string) error {
+			defined := make(map[string]AdapterType, len(c.Adapters))
+			for _, adapterType := range c.Adapters {
+				defined[adapterType.Type] = adapterType
+			}
+
+			c.Adapters = nil
+
+			for _, name := range strings.Split(value, ",") {
+				name = strings.TrimSpace(name)
+				if name == "" {
+					continue
+				}
+
+				adapterType, found := defined[name]
+				if !found {
+					adapterType = AdapterType{Type: name}
+				}
+
+				c.Adapters = append(c.Adapters, adapterType)
+			}
+
+			return nil
+		},
+	},
+	durationSetting("readiness-interval", "ADAPTER_READINESS_INTERVAL", "The interval between two runs of the readiness checks",
+		func(c *Config) *time.Duration { return &c.Readiness.Interval }),
+}
+
+// Load returns the configuration of the adapter server, merged from (in increasing order of
+// precedence) the defaults, the configuration file set by the -config flag or the ADAPTER_CONFIG
+// environment variable, the ADAPTER_* environment variables and the flags in args.
+// The configuration file may be in YAML or JSON. The merged configuration is validated.
+func Load(name string, args []string, lookupEnv func(string) (string, bool), output io.Writer) (*Config, error) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(output)
+
+	configFile := fs.String("config", "", fmt.Sprintf("The path of a YAML or JSON configuration file (env %s)", ConfigFileEnv))
+
+	// Flags are applied once the file and environment variables are, in the order they are passed.
+	type flagValue struct {
+		setting setting
+		value   string
+	}
+
+	var flagValues []flagValue
+
+	for _, s := range settings {
+		s := s
+		usage := fmt.Sprintf("%s (env %s)", s.usage, s.env)
+		record := func(value string) error {
+			// Parse the value now for the flag package to report an invalid value with the usage.
+			if err := s.set(Default(), value); err != nil {
+				return err
+			}
+
+			flagValues = append(flagValues, flagValue{setting: s, value: value})
+
+			return nil
+		}
+
+		if s.isBool {
+			fs.BoolFunc(s.flag, usage, record)
+		} else {
+			fs.Func(s.flag, usage, record)
+		}
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	config := Default()
+
+	path := *configFile
+	if path == "" {
+		path, _ = lookupEnv(ConfigFileEnv)
+	}
+
+	if path != "" {
+		if err := config.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, s := range settings {
+		value, found := lookupEnv(s.env)
+		if !found {
+			continue
+		}
+
+		if err := s.set(config, value); err != nil {
+			return nil, fmt.Errorf("invalid value %q for environment variable %s: %v", value, s.env, err)
+		}
+	}
+
+	for _, fv := range flagValues {
+		if err := fv.setting.set(config, fv.value); err != nil {
+			return nil, fmt.Errorf("invalid value %q for flag -%s: %v", fv.value, fv.setting.flag, err)
+		}
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+
+	return config, nil
+}
+
+// loadFile overrides the configuration with the fields set in the YAML or JSON file at path.
+// Unknown fields are rejected to catch typos.
+func (c *Config) loadFile(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read configuration file: %w", err)
+	}
+
+	// JSON is a subset of YAML, so both are parsed by the YAML decoder.
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse configuration file %s: %w", path, err)
+	}
+
+	return nil
+}
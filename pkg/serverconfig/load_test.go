@@ -0,0 +1,233 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serverconfig_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tksarunachalam/sgnl-adapter/pkg/serverconfig"
+)
+
+func TestLoad(t *testing.T) {
+	tests := map[string]struct {
+		// file is the content of the configuration file, set by ADAPTER_CONFIG unless args set -config.
+		file string
+		env  map[string]string
+		args []string
+
+		// want updates the default configuration to the expected one.
+		want func(c *serverconfig.Config)
+
+		// wantErr are substrings of the expected error.
+		wantErr []string
+	}{
+		"defaults": {
+			want: func(c *serverconfig.Config) {},
+		},
+		"file": {
+			file: "listenAddress: :9090\nlogging:\n  level: debug\nretry:\n  maxBackoff: 10s\n",
+			want: func(c *serverconfig.Config) {
+				c.ListenAddress = ":9090"
+				c.Logging.Level = "debug"
+				c.Retry.MaxBackoff = 10 * time.Second
+			},
+		},
+		"json_file": {
+			file: `{"listenAddress": ":9090", "metrics": {"port": 9100}}`,
+			want: func(c *serverconfig.Config) {
+				c.ListenAddress = ":9090"
+				c.Metrics.Port = 9100
+			},
+		},
+		"env_overrides_file": {
+			file: "listenAddress: :9090\nlogging:\n  level: debug\n",
+			env:  map[string]string{"ADAPTER_LISTEN_ADDRESS": ":9091"},
+			want: func(c *serverconfig.Config) {
+				c.ListenAddress = ":9091"
+				c.Logging.Level = "debug"
+			},
+		},
+		"flag_overrides_env_and_file": {
+			file: "listenAddress: :9090\n",
+			env:  map[string]string{"ADAPTER_LISTEN_ADDRESS": ":9091", "ADAPTER_LOG_FORMAT": "text"},
+			args: []string{"-listen-address", ":9092"},
+			want: func(c *serverconfig.Config) {
+				c.ListenAddress = ":9092"
+				c.Logging.Format = "text"
+			},
+		},
+		"flags_in_order": {
+			args: []string{"-listen-address", "127.0.0.1:9001", "-port", "9000"},
+			want: func(c *serverconfig.Config) {
+				c.ListenAddress = ":9000"
+			},
+		},
+		"flag_types": {
+			args: []string{
+				"-timeout", "60",
+				"-request-timeout", "2s",
+				"-rate-limit", "2.5",
+				"-rate-limit-burst", "5",
+				"-readiness-deep",
+			},
+			want: func(c *serverconfig.Config) {
+				c.Timeouts.HTTPClient = time.Minute
+				c.Timeouts.Request = 2 * time.Second
+				c.RateLimit.RequestsPerSecond = 2.5
+				c.RateLimit.Burst = 5
+				c.Readiness.Deep = true
+			},
+		},
+		"adapter_types_merge_file": {
+			file: `adapters:
+  - type: PagerDuty-1.0.0
+    entities: [users, teams]
+    config:
+      apiVersion: v1
+  - type: PagerDuty-0.9.0
+`,
+			env: map[string]string{"ADAPTER_TYPES": "PagerDuty-1.0.0, PagerDuty-2.0.0,"},
+			want: func(c *serverconfig.Config) {
+				c.Adapters = []serverconfig.AdapterType{
+					{Type: "PagerDuty-1.0.0", Entities: []string{"users", "teams"}, Config: map[string]any{"apiVersion": "v1"}},
+					{Type: "PagerDuty-2.0.0"},
+				}
+			},
+		},
+		"adapter_types_flag_overrides_env": {
+			env:  map[string]string{"ADAPTER_TYPES": "PagerDuty-1.0.0"},
+			args: []string{"-adapter-types", "PagerDuty-2.0.0"},
+			want: func(c *serverconfig.Config) {
+				c.Adapters = []serverconfig.AdapterType{{Type: "PagerDuty-2.0.0"}}
+			},
+		},
+		"invalid_env_value": {
+			env:     map[string]string{"ADAPTER_PORT": "http"},
+			wantErr: []string{`invalid value "http" for environment variable ADAPTER_PORT`},
+		},
+		"invalid_flag_value": {
+			args:    []string{"-request-timeout", "soon"},
+			wantErr: []string{`invalid value "soon" for flag -request-timeout`},
+		},
+		"unknown_file_field": {
+			file:    "listenAdress: :9090\n",
+			wantErr: []string{"failed to parse configuration file", "listenAdress"},
+		},
+		"validation_errors_aggregated": {
+			file: "logging:\n  level: verbose\n",
+			env:  map[string]string{"ADAPTER_METRICS_PORT": "70000", "ADAPTER_TLS_KEY": "server.key"},
+			args: []string{"-retry-max-attempts", "0", "-adapter-types", ","},
+			wantErr: []string{
+				"invalid configuration",
+				`logging.level "verbose" is invalid`,
+				"metrics.port 70000 is not a valid port",
+				"tls.certFile and tls.keyFile must be set together",
+				"retry.maxAttempts must be at least 1",
+				"adapters must contain at least one adapter type",
+			},
+		},
+		"invalid_adapter_config": {
+			file:    "adapters:\n  - type: PagerDuty-1.0.0\n    config:\n      since: yesterday\n",
+			wantErr: []string{"adapters[0].config is invalid: since must be an RFC3339 timestamp"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			env := make(map[string]string, len(tt.env)+1)
+			for key, value := range tt.env {
+				env[key] = value
+			}
+
+			if tt.file != "" {
+				path := filepath.Join(t.TempDir(), "config.yaml")
+				if err := os.WriteFile(path, []byte(tt.file), 0o600); err != nil {
+					t.Fatal(err)
+				}
+
+				env[serverconfig.ConfigFileEnv] = path
+			}
+
+			lookupEnv := func(key string) (string, bool) {
+				value, found := env[key]
+
+				return value, found
+			}
+
+			got, err := serverconfig.Load("adapter", tt.args, lookupEnv, io.Discard)
+
+			if len(tt.wantErr) > 0 {
+				if err == nil {
+					t.Fatalf("got no error, want %q", tt.wantErr)
+				}
+
+				for _, wantErr := range tt.wantErr {
+					if !strings.Contains(err.Error(), wantErr) {
+						t.Errorf("got error %q, want it to contain %q", err, wantErr)
+					}
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := serverconfig.Default()
+			tt.want(want)
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got config %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestLoadConfigFlag(t *testing.T) {
+	dir := t.TempDir()
+
+	envFile := filepath.Join(dir, "env.yaml")
+	flagFile := filepath.Join(dir, "flag.yaml")
+
+	for path, content := range map[string]string{envFile: "listenAddress: :9090\n", flagFile: "listenAddress: :9091\n"} {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lookupEnv := func(key string) (string, bool) {
+		if key == serverconfig.ConfigFileEnv {
+			return envFile, true
+		}
+
+		return "", false
+	}
+
+	config, err := serverconfig.Load("adapter", []string{"-config", flagFile}, lookupEnv, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.ListenAddress != ":9091" {
+		t.Errorf("got listen address %q, want the one of the -config file", config.ListenAddress)
+	}
+}
@@ -0,0 +1,348 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tlsreload_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tksarunachalam/sgnl-adapter/pkg/tlsreload"
+)
+
+// authority is a test certificate authority.
+type authority struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newAuthority(t *testing.T, name string) *authority {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &authority{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+func (a *authority) pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(a.cert)
+
+	return pool
+}
+
+// issue returns the PEM certificate and key of a leaf certificate signed by the authority, for a
+// server on 127.0.0.1 or for a client.
+func (a *authority) issue(t *testing.T, serial int64, usage x509.ExtKeyUsage) (certPEM, keyPEM []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "adapter"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, a.cert, &key.PublicKey, a.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// writeFile writes the file with a modification time after the previous one, so that the
+// rotation is detected even on file systems with a coarse time resolution.
+func writeFile(t *testing.T, path string, data []byte, modTime time.Time) {
+	t.Helper()
+
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// serve serves TLS connections with the config until the test ends, writing a byte to clients
+// once the handshake succeeds. Returns the address of the server.
+func serve(t *testing.T, config *tls.Config) string {
+	t.Helper()
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+
+				if conn.(*tls.Conn).Handshake() == nil {
+					_, _ = conn.Write([]byte{1})
+				}
+			}()
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+// dial connects to the server, and returns the certificate it served once it accepted the
+// connection.
+func dial(addr string, config *tls.Config) (*x509.Certificate, error) {
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 5 * time.Second}, "tcp", addr, config)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	// With TLS 1.3, client certificates are verified after the client completes the handshake, so
+	// a rejection is only known when reading.
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	if _, err := conn.Read(make([]byte, 1)); err != nil {
+		return nil, err
+	}
+
+	return conn.ConnectionState().PeerCertificates[0], nil
+}
+
+func TestReloaderRotation(t *testing.T) {
+	ca := newAuthority(t, "server CA")
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+	modTime := time.Now().Add(-time.Minute)
+
+	certPEM, keyPEM := ca.issue(t, 1, x509.ExtKeyUsageServerAuth)
+	writeFile(t, certFile, certPEM, modTime)
+	writeFile(t, keyFile, keyPEM, modTime)
+
+	reloader, err := tlsreload.New(certFile, keyFile, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errs := make(chan error, 10)
+	stop := make(chan struct{})
+	t.Cleanup(func() { close(stop) })
+
+	go reloader.Watch(stop, 10*time.Millisecond, func(err error) { errs <- err })
+
+	addr := serve(t, reloader.TLSConfig())
+	clientConfig := &tls.Config{RootCAs: ca.pool(), MinVersion: tls.VersionTLS12}
+
+	// served returns the serial number of the certificate served once it is the wanted one.
+	served := func(want int64) int64 {
+		t.Helper()
+
+		var got int64
+
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+			cert, err := dial(addr, clientConfig)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got = cert.SerialNumber.Int64(); got == want {
+				break
+			}
+		}
+
+		return got
+	}
+
+	if got := served(1); got != 1 {
+		t.Fatalf("Got certificate %d, want 1", got)
+	}
+
+	// The key is written before the certificate, as when a secret is rotated file by file.
+	certPEM, keyPEM = ca.issue(t, 2, x509.ExtKeyUsageServerAuth)
+	modTime = modTime.Add(time.Second)
+	writeFile(t, keyFile, keyPEM, modTime)
+	writeFile(t, certFile, certPEM, modTime)
+
+	if got := served(2); got != 2 {
+		t.Fatalf("Got certificate %d after the rotation, want 2", got)
+	}
+
+	// An invalid certificate is reported, and the previous one is still served.
+	modTime = modTime.Add(time.Second)
+	writeFile(t, certFile, []byte("not a certificate"), modTime)
+
+	select {
+	case <-errs:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Got no error for the invalid certificate")
+	}
+
+	if cert, err := dial(addr, clientConfig); err != nil || cert.SerialNumber.Int64() != 2 {
+		t.Errorf("Got certificate %v, %v after an invalid rotation, want 2", cert, err)
+	}
+}
+
+func TestReloaderClientAuth(t *testing.T) {
+	serverCA := newAuthority(t, "server CA")
+	clientCA := newAuthority(t, "client CA")
+	otherCA := newAuthority(t, "other CA")
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+	clientCAFile := filepath.Join(dir, "ca.crt")
+
+	certPEM, keyPEM := serverCA.issue(t, 1, x509.ExtKeyUsageServerAuth)
+	writeFile(t, certFile, certPEM, time.Now())
+	writeFile(t, keyFile, keyPEM, time.Now())
+	writeFile(t, clientCAFile, clientCA.pem, time.Now())
+
+	reloader, err := tlsreload.New(certFile, keyFile, clientCAFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr := serve(t, reloader.TLSConfig())
+
+	// clientCertificate returns the client certificate issued by the authority.
+	clientCertificate := func(ca *authority) []tls.Certificate {
+		certPEM, keyPEM := ca.issue(t, 3, x509.ExtKeyUsageClientAuth)
+
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return []tls.Certificate{cert}
+	}
+
+	tests := map[string]struct {
+		certificates []tls.Certificate
+		wantErr      bool
+	}{
+		"trusted_client": {
+			certificates: clientCertificate(clientCA),
+		},
+		"no_client_certificate": {
+			wantErr: true,
+		},
+		"untrusted_client": {
+			certificates: clientCertificate(otherCA),
+			wantErr:      true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := dial(addr, &tls.Config{
+				RootCAs:      serverCA.pool(),
+				Certificates: tt.certificates,
+				MinVersion:   tls.VersionTLS12,
+			})
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Got error %v, want error %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewInvalidFiles(t *testing.T) {
+	ca := newAuthority(t, "server CA")
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+	emptyCAFile := filepath.Join(dir, "empty.crt")
+
+	certPEM, keyPEM := ca.issue(t, 1, x509.ExtKeyUsageServerAuth)
+	writeFile(t, certFile, certPEM, time.Now())
+	writeFile(t, keyFile, keyPEM, time.Now())
+	writeFile(t, emptyCAFile, nil, time.Now())
+
+	tests := map[string]struct {
+		certFile, keyFile, clientCAFile string
+	}{
+		"no_key":              {certFile: certFile},
+		"missing_certificate": {certFile: filepath.Join(dir, "missing.crt"), keyFile: keyFile},
+		"key_mismatch":        {certFile: certFile, keyFile: certFile},
+		"missing_client_ca":   {certFile: certFile, keyFile: keyFile, clientCAFile: filepath.Join(dir, "missing.crt")},
+		"empty_client_ca":     {certFile: certFile, keyFile: keyFile, clientCAFile: emptyCAFile},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := tlsreload.New(tt.certFile, tt.keyFile, tt.clientCAFile); err == nil {
+				t.Error("Got no error")
+			}
+		})
+	}
+}
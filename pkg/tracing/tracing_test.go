@@ -0,0 +1,242 @@
+// Copyright 2023 SGNL.ai, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing_test
+
+import (
+	"context"
+	"encoding/hex"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	framework "github.com/sgnl-ai/adapter-framework"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/adapter"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/adaptertest"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/logging"
+	"github.com/tksarunachalam/sgnl-adapter/pkg/tracing"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/grpc"
+)
+
+func TestEnabled(t *testing.T) {
+	tests := map[string]struct {
+		env  map[string]string
+		want bool
+	}{
+		"no_endpoint": {
+			want: false,
+		},
+		"endpoint": {
+			env:  map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://localhost:4317"},
+			want: true,
+		},
+		"traces_endpoint": {
+			env:  map[string]string{"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT": "http://localhost:4317"},
+			want: true,
+		},
+		"sdk_disabled": {
+			env: map[string]string{
+				"OTEL_EXPORTER_OTLP_ENDPOINT": "http://localhost:4317",
+				"OTEL_SDK_DISABLED":           "TRUE",
+			},
+			want: false,
+		},
+		"exporter_none": {
+			env: map[string]string{
+				"OTEL_EXPORTER_OTLP_ENDPOINT": "http://localhost:4317",
+				"OTEL_TRACES_EXPORTER":        "none",
+			},
+			want: false,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			for _, key := range []string{
+				"OTEL_EXPORTER_OTLP_ENDPOINT", "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "OTEL_SDK_DISABLED", "OTEL_TRACES_EXPORTER",
+			} {
+				t.Setenv(key, tt.env[key])
+			}
+
+			if got := tracing.Enabled(); got != tt.want {
+				t.Errorf("got %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+// setUp is true once TestSetup registered the global tracer provider. The adapter's tracer is
+// bound to the first registered provider, so later runs of the test, e.g. with -count, can't
+// observe its spans.
+var setUp bool
+
+// TestSetup verifies that the spans of a GetPage request are exported over OTLP/gRPC to the
+// configured endpoint, as a tree rooted at the Adapter.GetPage span.
+func TestSetup(t *testing.T) {
+	if setUp {
+		t.Skip("the global tracer provider was already registered by a previous run")
+	}
+
+	setUp = true
+
+	collector := &collector{}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := grpc.NewServer()
+	coltracepb.RegisterTraceServiceServer(server, collector)
+
+	go server.Serve(listener)
+	defer server.Stop()
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://"+listener.Addr().String())
+	t.Setenv("OTEL_EXPORTER_OTLP_INSECURE", "true")
+	t.Setenv("OTEL_SDK_DISABLED", "")
+	t.Setenv("OTEL_TRACES_EXPORTER", "")
+	t.Setenv("OTEL_SERVICE_NAME", "")
+	t.Setenv("OTEL_RESOURCE_ATTRIBUTES", "")
+
+	shutdown, err := tracing.Setup(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock, client := adaptertest.NewMockClient(t, nil)
+
+	a := &adapter.Adapter{Client: client, Logger: logging.Discard()}
+
+	response := a.GetPage(context.Background(), &framework.Request[adapter.Config]{
+		Address: strings.TrimPrefix(mock.URL, "https://"),
+		Auth:    &framework.DatasourceAuthCredentials{HTTPAuthorization: "Token token=test"},
+		Config:  &adapter.Config{},
+		Entity: framework.EntityConfig{
+			ExternalId: adapter.Users,
+			Attributes: []*framework.AttributeConfig{{ExternalId: "id", Type: framework.AttributeTypeString}},
+		},
+		PageSize: 10,
+	})
+	if response.Error != nil {
+		t.Fatalf("got error %s: %s", response.Error.Code, response.Error.Message)
+	}
+
+	// Shutting down flushes the batched spans to the collector.
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"Adapter.GetPage",
+		"  Adapter.ValidateGetPageRequest",
+		"  Datasource.GetPage",
+		"    HTTP GET",
+		"    ParseResponse",
+		"  web.ConvertJSONObjectList",
+	}
+
+	if got := collector.tree(); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got spans:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	if got := collector.serviceName(); got != tracing.DefaultServiceName {
+		t.Errorf("got service name %q, want %q", got, tracing.DefaultServiceName)
+	}
+}
+
+// collector is an OTLP trace collector that keeps the exported spans.
+type collector struct {
+	coltracepb.UnimplementedTraceServiceServer
+
+	mu       sync.Mutex
+	requests []*coltracepb.ExportTraceServiceRequest
+}
+
+func (c *collector) Export(_ context.Context, request *coltracepb.ExportTraceServiceRequest) (*coltracepb.ExportTraceServiceResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.requests = append(c.requests, request)
+
+	return &coltracepb.ExportTraceServiceResponse{}, nil
+}
+
+// span is an exported span.
+type span struct {
+	id, name string
+	start    uint64
+}
+
+// tree returns the names of the exported spans, indented by depth, with the children of each span
+// in the order they started.
+func (c *collector) tree() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	children := make(map[string][]span)
+
+	for _, request := range c.requests {
+		for _, resourceSpans := range request.ResourceSpans {
+			for _, scopeSpans := range resourceSpans.ScopeSpans {
+				for _, s := range scopeSpans.Spans {
+					parentID := hex.EncodeToString(s.ParentSpanId)
+					children[parentID] = append(children[parentID], span{
+						id:    hex.EncodeToString(s.SpanId),
+						name:  s.Name,
+						start: s.StartTimeUnixNano,
+					})
+				}
+			}
+		}
+	}
+
+	var lines []string
+
+	var walk func(parentID, indent string)
+	walk = func(parentID, indent string) {
+		spans := children[parentID]
+		sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+		for _, s := range spans {
+			lines = append(lines, indent+s.name)
+			walk(s.id, indent+"  ")
+		}
+	}
+
+	walk("", "")
+
+	return lines
+}
+
+// serviceName returns the service.name resource attribute of the first exported spans.
+func (c *collector) serviceName() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, request := range c.requests {
+		for _, resourceSpans := range request.ResourceSpans {
+			for _, attribute := range resourceSpans.Resource.GetAttributes() {
+				if attribute.Key == "service.name" {
+					return attribute.Value.GetStringValue()
+				}
+			}
+		}
+	}
+
+	return ""
+}